@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// normalizedUsersVersion is the migration that makes usernames and emails
+// case insensitive, it fails while there are users colliding.
+const normalizedUsersVersion = 14
+
+var errUsersCollisions = errors.New("users with the same normalized username or email")
+
+// userCollision is a group of users that are not deleted and have the same
+// username or email once they are normalized like the migration does.
+type userCollision struct {
+	Field string         `db:"field"`
+	Value string         `db:"value"`
+	Users pq.StringArray `db:"users"`
+}
+
+func getUsersCollisions(db *sqlx.DB) ([]userCollision, error) {
+	exist := false
+
+	err := db.Get(&exist, "SELECT to_regclass('users') IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("error checking users table: %w", err)
+	}
+
+	collisions := []userCollision{}
+
+	if !exist {
+		return collisions, nil
+	}
+
+	err = db.Select(
+		&collisions,
+		`SELECT 'username' AS field, lower(normalize(username, NFKC)) AS value,
+			array_agg(id::text ORDER BY created_at) AS users
+		FROM users
+		WHERE deleted_at = $1
+		GROUP BY lower(normalize(username, NFKC))
+		HAVING count(*) > 1
+		UNION ALL
+		SELECT 'email' AS field, lower(email) AS value,
+			array_agg(id::text ORDER BY created_at) AS users
+		FROM users
+		WHERE deleted_at = $1
+		GROUP BY lower(email)
+		HAVING count(*) > 1
+		ORDER BY field, value`,
+		time.Time{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting users collisions: %w", err)
+	}
+
+	return collisions, nil
+}
+
+// checkUsersCollisions reports the users that would collide once usernames and
+// emails are case insensitive. They must be renamed or deleted before the
+// migration runs.
+func checkUsersCollisions(db *sqlx.DB) error {
+	collisions, err := getUsersCollisions(db)
+	if err != nil {
+		return err
+	}
+
+	for _, collision := range collisions {
+		log.Printf(
+			"[ERROR] - Users %s have the %s '%s'",
+			strings.Join(collision.Users, ", "),
+			collision.Field,
+			collision.Value,
+		)
+	}
+
+	if len(collisions) > 0 {
+		return fmt.Errorf("%w: %d collisions", errUsersCollisions, len(collisions))
+	}
+
+	log.Printf("[INFO] - No users with the same normalized username or email")
+
+	return nil
+}
+
+// needsCollisionsCheck reports whether the database is before the migration
+// of normalized users, a new database has no users to check.
+func needsCollisionsCheck(migrations *migrate.Migrate) bool {
+	version, _, err := migrations.Version()
+
+	return err == nil && version < normalizedUsersVersion
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/knadh/koanf/parsers/dotenv"
@@ -41,11 +43,58 @@ type redisConfig struct {
 	DB       int    `config:"db"       validate:"min=0"`
 }
 
+type passwordConfig struct {
+	History int           `config:"history" validate:"min=0"`
+	MaxAge  time.Duration `config:"max_age" validate:"min=0"`
+}
+
+type sessionConfig struct {
+	IdleTimeout            time.Duration `config:"idle_timeout"             validate:"min=1s"`
+	MaxLifetime            time.Duration `config:"max_lifetime"             validate:"gtefield=IdleTimeout"`
+	RememberIdleTimeout    time.Duration `config:"remember_idle_timeout"    validate:"min=1s"`
+	RememberMaxLifetime    time.Duration `config:"remember_max_lifetime"    validate:"gtefield=RememberIdleTimeout"`
+	RefreshThreshold       time.Duration `config:"refresh_threshold"        validate:"min=0,ltefield=IdleTimeout"`
+	RefreshGrace           time.Duration `config:"refresh_grace"            validate:"min=0,ltefield=IdleTimeout"`
+	MaxPerUser             int           `config:"max_per_user"             validate:"min=0"`
+	MaxPerRole             string        `config:"max_per_role"             validate:""`
+	LimitPolicy            string        `config:"limit_policy"             validate:"oneof=reject evict"`
+	ReauthenticationMaxAge time.Duration `config:"reauthentication_max_age" validate:"min=1s"`
+	ImpersonatorRole       string        `config:"impersonator_role"        validate:""`
+}
+
+type cookieConfig struct {
+	Enabled    bool   `config:"enabled"     validate:""`
+	Name       string `config:"name"        validate:"required"`
+	CSRFName   string `config:"csrf_name"   validate:"required,nefield=Name"`
+	Domain     string `config:"domain"      validate:""`
+	Path       string `config:"path"        validate:"required"`
+	Secure     bool   `config:"secure"      validate:""`
+	SameSite   string `config:"same_site"   validate:"oneof=strict lax none"`
+	CSRFSecret string `config:"csrf_secret" validate:""`
+}
+
+type hashConfig struct {
+	Algorithm           string `config:"algorithm"            validate:"oneof=argon2id bcrypt scrypt"`
+	Argon2idMemory      uint32 `config:"argon2id_memory"      validate:"min=1"`
+	Argon2idIterations  uint32 `config:"argon2id_iterations"  validate:"min=1"`
+	Argon2idParallelism uint8  `config:"argon2id_parallelism" validate:"min=1"`
+	BcryptCost          int    `config:"bcrypt_cost"          validate:"min=4,max=31"`
+	ScryptN             int    `config:"scrypt_n"             validate:"min=2"`
+	ScryptR             int    `config:"scrypt_r"             validate:"min=1"`
+	ScryptP             int    `config:"scrypt_p"             validate:"min=1"`
+	PoolConcurrency     int    `config:"pool_concurrency"     validate:"min=1"`
+	PoolQueue           int    `config:"pool_queue"           validate:"min=0"`
+}
+
 type configurations struct {
 	User     admin          `config:"user"     validate:"required"`
 	Role     roleAdmin      `config:"role"     validate:"required"`
 	Postgres postgresConfig `config:"postgres" validate:"required"`
 	Redis    redisConfig    `config:"redis"    validate:"required"`
+	Password passwordConfig `config:"password" validate:"required"`
+	Hash     hashConfig     `config:"hash"     validate:"required"`
+	Session  sessionConfig  `config:"session"  validate:"required"`
+	Cookie   cookieConfig   `config:"cookie"   validate:"required"`
 	DevMode  bool           `config:"dev"      validate:""`
 }
 
@@ -75,6 +124,45 @@ func defaultConfigurations() configurations {
 			Password: "redis",
 			DB:       0,
 		},
+		Password: passwordConfig{
+			History: 5,
+			MaxAge:  time.Hour * 24 * 90,
+		},
+		Hash: hashConfig{
+			Algorithm:           "argon2id",
+			Argon2idMemory:      64 * 1024,
+			Argon2idIterations:  1,
+			Argon2idParallelism: 2,
+			BcryptCost:          12,
+			ScryptN:             32768,
+			ScryptR:             8,
+			ScryptP:             1,
+			PoolConcurrency:     runtime.NumCPU(),
+			PoolQueue:           100,
+		},
+		Session: sessionConfig{
+			IdleTimeout:            time.Hour,
+			MaxLifetime:            time.Hour * 24,
+			RememberIdleTimeout:    time.Hour * 24 * 7,
+			RememberMaxLifetime:    time.Hour * 24 * 30,
+			RefreshThreshold:       time.Minute * 15,
+			RefreshGrace:           time.Second * 10,
+			MaxPerUser:             0,
+			MaxPerRole:             "",
+			LimitPolicy:            "reject",
+			ReauthenticationMaxAge: time.Minute * 5,
+			ImpersonatorRole:       "",
+		},
+		Cookie: cookieConfig{
+			Enabled:    false,
+			Name:       "session",
+			CSRFName:   "csrf_token",
+			Domain:     "",
+			Path:       "/",
+			Secure:     true,
+			SameSite:   "strict",
+			CSRFSecret: "",
+		},
 		DevMode: true,
 	}
 }
@@ -2,6 +2,8 @@ package core
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	ut "github.com/go-playground/universal-translator"
@@ -33,6 +35,33 @@ func NewInvalidError(errs validator.ValidationErrors) InvalidError {
 	return InvalidError{errs}
 }
 
+type PasswordReusedError struct {
+	history int
+}
+
+func (p PasswordReusedError) Error() string {
+	return fmt.Sprintf("%s: must differ from the last %d passwords", errs.ErrPasswordReused, p.history)
+}
+
+func (p PasswordReusedError) Unwrap() error {
+	return errs.ErrPasswordReused
+}
+
+func (p PasswordReusedError) Translate(language ut.Translator) string {
+	message, err := language.T(PasswordReusedTranslation, strconv.Itoa(p.history))
+	if err != nil {
+		return p.Error()
+	}
+
+	return message
+}
+
+const PasswordReusedTranslation = "password_reused"
+
+func NewPasswordReusedError(history int) PasswordReusedError {
+	return PasswordReusedError{history}
+}
+
 func Validate(validate *validator.Validate, data any) error {
 	err := validate.Struct(data)
 	if err != nil {
@@ -53,21 +82,45 @@ type Cores struct {
 	*Role
 	*User
 	*UserSession
+	*Token
 }
 
 func NewCore(
 	data *data.Data,
 	validate *validator.Validate,
-	argonEnable bool,
-	expires time.Duration,
+	hash PasswordHash,
+	pool *PasswordPool,
+	passwordHistory int,
+	passwordMaxAge time.Duration,
+	idleTimeout time.Duration,
+	maxLifetime time.Duration,
+	rememberIdleTimeout time.Duration,
+	rememberMaxLifetime time.Duration,
+	refreshThreshold time.Duration,
+	refreshGrace time.Duration,
+	sessionLimit SessionLimit,
 ) *Cores {
 	role := NewRole(data.Role, validate)
-	user := NewUser(data.User, role, validate, argonEnable)
-	userSession := NewUserSession(data.UserSession, user, validate, expires)
+	user := NewUser(data.User, role, validate, hash, pool, passwordHistory, passwordMaxAge)
+	userSession := NewUserSession(
+		data.UserSession,
+		user,
+		validate,
+		idleTimeout,
+		maxLifetime,
+		rememberIdleTimeout,
+		rememberMaxLifetime,
+		refreshThreshold,
+		refreshGrace,
+		sessionLimit,
+	)
+
+	token := NewToken(data.Token, user, validate)
 
 	return &Cores{
 		Role:        role,
 		User:        user,
 		UserSession: userSession,
+		Token:       token,
 	}
 }
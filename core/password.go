@@ -0,0 +1,521 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	HashArgon2id       = "argon2id"
+	HashBcrypt         = "bcrypt"
+	HashScrypt         = "scrypt"
+	HashSHA256         = "sha256"
+	HashDjangoPBKDF2   = "django_pbkdf2"
+	HashDjangoBcrypt   = "django_bcrypt"
+	HashKeycloakPBKDF2 = "keycloak_pbkdf2"
+)
+
+const (
+	scryptSaltLength = 16
+	scryptKeyLength  = 32
+)
+
+// Limits of the parameters of the hashes verified. Imported hashes bring their
+// own parameters, without limits a single hash could make every login of its
+// user use unbounded CPU and memory in the password pool.
+const (
+	bcryptMaxCost       = 15
+	argon2idMaxMemory   = 256 * 1024 // KiB
+	argon2idMaxTime     = 16
+	argon2idMaxThreads  = 16
+	scryptMaxN          = 1 << 20
+	scryptMaxR          = 32
+	scryptMaxP          = 16
+	scryptMaxMemory     = 256 << 20
+	pbkdf2MaxIterations = 2_000_000
+	hashMaxKeyLength    = 128
+)
+
+var sha256Regex = regexp.MustCompile(`^[0-9a-f]{64}$`) //nolint:gochecknoglobals
+
+// PasswordHash configures how new password hashes are created. Hashes already
+// stored are verified with the algorithm detected from the hash string, so any
+// supported algorithm can be verified regardless of the configured one.
+type PasswordHash struct {
+	Algorithm  string
+	Argon2id   argon2id.Params
+	BcryptCost int
+	ScryptN    int
+	ScryptR    int
+	ScryptP    int
+}
+
+//nolint:gomnd
+func DefaultPasswordHash() PasswordHash {
+	return PasswordHash{
+		Algorithm:  HashArgon2id,
+		Argon2id:   *argon2id.DefaultParams,
+		BcryptCost: bcrypt.DefaultCost,
+		ScryptN:    32768,
+		ScryptR:    8,
+		ScryptP:    1,
+	}
+}
+
+func detectHash(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return HashArgon2id
+	case strings.HasPrefix(hash, "$2a$"),
+		strings.HasPrefix(hash, "$2b$"),
+		strings.HasPrefix(hash, "$2y$"):
+		return HashBcrypt
+	case strings.HasPrefix(hash, "$scrypt$"):
+		return HashScrypt
+	case sha256Regex.MatchString(hash):
+		return HashSHA256
+	case strings.HasPrefix(hash, "pbkdf2_sha256$"), strings.HasPrefix(hash, "pbkdf2_sha1$"):
+		return HashDjangoPBKDF2
+	case strings.HasPrefix(hash, "bcrypt$"), strings.HasPrefix(hash, "bcrypt_sha256$"):
+		return HashDjangoBcrypt
+	case strings.HasPrefix(hash, "keycloak$"):
+		return HashKeycloakPBKDF2
+	default:
+		return ""
+	}
+}
+
+func (p PasswordHash) Create(password string) (string, error) {
+	switch p.Algorithm {
+	case HashArgon2id:
+		hash, err := argon2id.CreateHash(password, &p.Argon2id)
+		if err != nil {
+			return "", fmt.Errorf("error creating password hash: %w", err)
+		}
+
+		return hash, nil
+
+	case HashBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("error creating password hash: %w", err)
+		}
+
+		return string(hash), nil
+
+	case HashScrypt:
+		salt := make([]byte, scryptSaltLength)
+
+		_, err := rand.Read(salt)
+		if err != nil {
+			return "", fmt.Errorf("error creating password salt: %w", err)
+		}
+
+		key, err := scrypt.Key([]byte(password), salt, p.ScryptN, p.ScryptR, p.ScryptP, scryptKeyLength)
+		if err != nil {
+			return "", fmt.Errorf("error creating password hash: %w", err)
+		}
+
+		return fmt.Sprintf(
+			"$scrypt$n=%d,r=%d,p=%d$%s$%s",
+			p.ScryptN,
+			p.ScryptR,
+			p.ScryptP,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+
+	default:
+		return "", fmt.Errorf("error creating password hash: %w: %s", errs.ErrHashUnknown, p.Algorithm)
+	}
+}
+
+type scryptHash struct {
+	n, r, p int
+	salt    []byte
+	key     []byte
+}
+
+func decodeScrypt(hash string) (scryptHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 { //nolint:gomnd
+		return scryptHash{}, errs.ErrHashUnknown
+	}
+
+	decoded := scryptHash{} //nolint:exhaustruct
+
+	_, err := fmt.Sscanf(parts[2], "n=%d,r=%d,p=%d", &decoded.n, &decoded.r, &decoded.p)
+	if err != nil {
+		return scryptHash{}, fmt.Errorf("error decoding scrypt params: %w", err)
+	}
+
+	decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return scryptHash{}, fmt.Errorf("error decoding scrypt salt: %w", err)
+	}
+
+	decoded.key, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return scryptHash{}, fmt.Errorf("error decoding scrypt key: %w", err)
+	}
+
+	if decoded.n <= 1 || decoded.n > scryptMaxN ||
+		decoded.r <= 0 || decoded.r > scryptMaxR ||
+		decoded.p <= 0 || decoded.p > scryptMaxP ||
+		128*decoded.n*decoded.r > scryptMaxMemory || //nolint:gomnd
+		len(decoded.key) > hashMaxKeyLength {
+		return scryptHash{}, errs.ErrHashParameters
+	}
+
+	return decoded, nil
+}
+
+func checkArgon2id(hash string) error {
+	params, _, _, err := argon2id.DecodeHash(hash)
+	if err != nil {
+		return fmt.Errorf("error decoding argon2id params: %w", err)
+	}
+
+	if params.Memory > argon2idMaxMemory ||
+		params.Iterations > argon2idMaxTime ||
+		params.Parallelism > argon2idMaxThreads ||
+		params.KeyLength > hashMaxKeyLength {
+		return errs.ErrHashParameters
+	}
+
+	return nil
+}
+
+func checkBcrypt(hash string) error {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return fmt.Errorf("error decoding bcrypt cost: %w", err)
+	}
+
+	if cost > bcryptMaxCost {
+		return errs.ErrHashParameters
+	}
+
+	return nil
+}
+
+// Check reports whether the hash can be verified, returning errs.ErrHashUnknown
+// for unknown formats and errs.ErrHashParameters for parameters above the
+// limits.
+func (p PasswordHash) Check(hash string) error {
+	var err error
+
+	switch detectHash(hash) {
+	case HashArgon2id:
+		err = checkArgon2id(hash)
+	case HashBcrypt:
+		err = checkBcrypt(hash)
+	case HashScrypt:
+		_, err = decodeScrypt(hash)
+	case HashSHA256:
+		err = nil
+	case HashDjangoPBKDF2:
+		_, err = decodeDjangoPBKDF2(hash)
+	case HashDjangoBcrypt:
+		_, bcryptHash, _ := strings.Cut(hash, "$")
+		err = checkBcrypt(bcryptHash)
+	case HashKeycloakPBKDF2:
+		_, err = decodeKeycloakPBKDF2(hash)
+	default:
+		err = errs.ErrHashUnknown
+	}
+
+	return err
+}
+
+func (p PasswordHash) Compare(password string, hash string) (bool, error) {
+	switch detectHash(hash) {
+	case HashArgon2id:
+		err := checkArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+
+		return argon2id.ComparePasswordAndHash(password, hash) //nolint:wrapcheck
+
+	case HashBcrypt:
+		err := checkBcrypt(hash)
+		if err != nil {
+			return false, err
+		}
+
+		err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if err != nil {
+			if err == bcrypt.ErrMismatchedHashAndPassword { //nolint:errorlint
+				return false, nil
+			}
+
+			return false, err //nolint:wrapcheck
+		}
+
+		return true, nil
+
+	case HashScrypt:
+		decoded, err := decodeScrypt(hash)
+		if err != nil {
+			return false, err
+		}
+
+		key, err := scrypt.Key(
+			[]byte(password),
+			decoded.salt,
+			decoded.n,
+			decoded.r,
+			decoded.p,
+			len(decoded.key),
+		)
+		if err != nil {
+			return false, err //nolint:wrapcheck
+		}
+
+		return subtle.ConstantTimeCompare(key, decoded.key) == 1, nil
+
+	case HashSHA256:
+		hashp := fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(hashp)) == 1, nil
+
+	case HashDjangoPBKDF2:
+		return compareDjangoPBKDF2(password, hash)
+
+	case HashDjangoBcrypt:
+		return compareDjangoBcrypt(password, hash)
+
+	case HashKeycloakPBKDF2:
+		return compareKeycloakPBKDF2(password, hash)
+
+	default:
+		return false, errs.ErrHashUnknown
+	}
+}
+
+// Supports reports whether the hash format is known and can be verified.
+func (p PasswordHash) Supports(hash string) bool {
+	return detectHash(hash) != ""
+}
+
+// NeedsRehash reports whether the stored hash was created with another
+// algorithm or with parameters different from the configured ones.
+func (p PasswordHash) NeedsRehash(hash string) bool {
+	algorithm := detectHash(hash)
+	if algorithm != p.Algorithm {
+		return true
+	}
+
+	switch algorithm {
+	case HashArgon2id:
+		params, _, _, err := argon2id.DecodeHash(hash)
+		if err != nil {
+			return true
+		}
+
+		return params.Memory != p.Argon2id.Memory ||
+			params.Iterations != p.Argon2id.Iterations ||
+			params.Parallelism != p.Argon2id.Parallelism ||
+			params.KeyLength != p.Argon2id.KeyLength
+
+	case HashBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+
+		return err != nil || cost != p.BcryptCost
+
+	case HashScrypt:
+		decoded, err := decodeScrypt(hash)
+
+		return err != nil || decoded.n != p.ScryptN || decoded.r != p.ScryptR || decoded.p != p.ScryptP
+
+	default:
+		return true
+	}
+}
+
+func pbkdf2Digest(algorithm string) (func() hash.Hash, bool) {
+	switch algorithm {
+	case "sha1":
+		return sha1.New, true
+	case "sha256":
+		return sha256.New, true
+	case "sha512":
+		return sha512.New, true
+	default:
+		return nil, false
+	}
+}
+
+type pbkdf2Hash struct {
+	digest     func() hash.Hash
+	iterations int
+	salt       []byte
+	key        []byte
+}
+
+func decodePBKDF2(
+	algorithm string,
+	iterations string,
+	salt []byte,
+	encodedKey string,
+) (pbkdf2Hash, error) {
+	digest, okay := pbkdf2Digest(algorithm)
+	if !okay {
+		return pbkdf2Hash{}, errs.ErrHashUnknown
+	}
+
+	iter, err := strconv.Atoi(iterations)
+	if err != nil || iter <= 0 {
+		return pbkdf2Hash{}, fmt.Errorf("%w: invalid pbkdf2 iterations", errs.ErrHashUnknown)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return pbkdf2Hash{}, fmt.Errorf("error decoding pbkdf2 key: %w", err)
+	}
+
+	if iter > pbkdf2MaxIterations || len(key) > hashMaxKeyLength {
+		return pbkdf2Hash{}, errs.ErrHashParameters
+	}
+
+	return pbkdf2Hash{digest: digest, iterations: iter, salt: salt, key: key}, nil
+}
+
+func (h pbkdf2Hash) compare(password string) bool {
+	derived := pbkdf2.Key([]byte(password), h.salt, h.iterations, len(h.key), h.digest)
+
+	return subtle.ConstantTimeCompare(derived, h.key) == 1
+}
+
+// decodeDjangoPBKDF2 decodes hashes in the Django format
+// "pbkdf2_<digest>$<iterations>$<salt>$<base64 key>".
+func decodeDjangoPBKDF2(hash string) (pbkdf2Hash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 { //nolint:gomnd
+		return pbkdf2Hash{}, errs.ErrHashUnknown
+	}
+
+	algorithm := strings.TrimPrefix(parts[0], "pbkdf2_")
+
+	return decodePBKDF2(algorithm, parts[1], []byte(parts[2]), parts[3])
+}
+
+func compareDjangoPBKDF2(password string, hash string) (bool, error) {
+	decoded, err := decodeDjangoPBKDF2(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return decoded.compare(password), nil
+}
+
+// compareDjangoBcrypt verifies hashes in the Django formats "bcrypt$<bcrypt>"
+// and "bcrypt_sha256$<bcrypt>", the latter hashing the hex SHA-256 of the password.
+func compareDjangoBcrypt(password string, hash string) (bool, error) {
+	algorithm, bcryptHash, _ := strings.Cut(hash, "$")
+
+	err := checkBcrypt(bcryptHash)
+	if err != nil {
+		return false, err
+	}
+
+	if algorithm == "bcrypt_sha256" {
+		password = fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(bcryptHash), []byte(password))
+	if err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword { //nolint:errorlint
+			return false, nil
+		}
+
+		return false, err //nolint:wrapcheck
+	}
+
+	return true, nil
+}
+
+// decodeKeycloakPBKDF2 decodes hashes created by KeycloakHash.
+func decodeKeycloakPBKDF2(hash string) (pbkdf2Hash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 { //nolint:gomnd
+		return pbkdf2Hash{}, errs.ErrHashUnknown
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return pbkdf2Hash{}, fmt.Errorf("error decoding pbkdf2 salt: %w", err)
+	}
+
+	return decodePBKDF2(parts[1], parts[2], salt, parts[4])
+}
+
+func compareKeycloakPBKDF2(password string, hash string) (bool, error) {
+	decoded, err := decodeKeycloakPBKDF2(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return decoded.compare(password), nil
+}
+
+// KeycloakHash converts the secret and credential data of a Keycloak password
+// credential into the format stored in the database,
+// "keycloak$<digest>$<iterations>$<base64 salt>$<base64 key>".
+func KeycloakHash(secretData string, credentialData string) (string, error) {
+	secret := struct {
+		Value string `json:"value"`
+		Salt  string `json:"salt"`
+	}{}
+
+	err := json.Unmarshal([]byte(secretData), &secret)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid keycloak secret data", errs.ErrHashUnknown)
+	}
+
+	credential := struct {
+		HashIterations int    `json:"hashIterations"`
+		Algorithm      string `json:"algorithm"`
+	}{}
+
+	err = json.Unmarshal([]byte(credentialData), &credential)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid keycloak credential data", errs.ErrHashUnknown)
+	}
+
+	digests := map[string]string{
+		"pbkdf2":        "sha1",
+		"pbkdf2-sha256": "sha256",
+		"pbkdf2-sha512": "sha512",
+	}
+
+	digest, okay := digests[credential.Algorithm]
+	if !okay || secret.Value == "" || secret.Salt == "" || credential.HashIterations <= 0 {
+		return "", fmt.Errorf("%w: keycloak %s", errs.ErrHashUnknown, credential.Algorithm)
+	}
+
+	return fmt.Sprintf(
+		"keycloak$%s$%d$%s$%s",
+		digest,
+		credential.HashIterations,
+		secret.Salt,
+		secret.Value,
+	), nil
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+)
+
+type passwordJob struct {
+	operation string
+	queued    time.Time
+	work      func()
+	done      chan error
+}
+
+// PasswordPool runs password hashing in a fixed number of workers, so a burst
+// of logins can not allocate more hashing memory than the workers use. Jobs
+// that do not fit in the queue are rejected instead of waiting.
+type PasswordPool struct {
+	jobs     chan passwordJob
+	wait     prometheus.Histogram
+	duration *prometheus.HistogramVec
+	rejected prometheus.Counter
+	queued   prometheus.GaugeFunc
+}
+
+func (p *PasswordPool) worker() {
+	for job := range p.jobs {
+		p.wait.Observe(time.Since(job.queued).Seconds())
+
+		start := time.Now()
+		err := runRecovered(job.work)
+		p.duration.WithLabelValues(job.operation).Observe(time.Since(start).Seconds())
+
+		job.done <- err
+		close(job.done)
+	}
+}
+
+// runRecovered runs work returning a panic as an error, so a panicking hash
+// does not kill the worker nor leave the caller waiting.
+func runRecovered(work func()) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			err = fmt.Errorf("%w: %v", errs.ErrPasswordPanic, recovered)
+		}
+	}()
+
+	work()
+
+	return nil
+}
+
+// Run executes work in the pool and waits for it, returning
+// errs.ErrPasswordPoolFull when all workers are busy and the queue is full and
+// errs.ErrPasswordPanic when work panics. A nil pool executes work
+// directly.
+func (p *PasswordPool) Run(operation string, work func()) error {
+	if p == nil {
+		return runRecovered(work)
+	}
+
+	job := passwordJob{
+		operation: operation,
+		queued:    time.Now(),
+		work:      work,
+		done:      make(chan error, 1),
+	}
+
+	select {
+	case p.jobs <- job:
+	default:
+		p.rejected.Inc()
+
+		return errs.ErrPasswordPoolFull
+	}
+
+	return <-job.done
+}
+
+func NewPasswordPool(
+	concurrency int,
+	queue int,
+	registerer prometheus.Registerer,
+) (*PasswordPool, error) {
+	if concurrency <= 0 || queue < 0 {
+		return nil, errs.ErrPoolConfiguration
+	}
+
+	pool := &PasswordPool{
+		jobs: make(chan passwordJob, queue),
+		wait: prometheus.NewHistogram(prometheus.HistogramOpts{ //nolint:exhaustruct
+			Namespace: "autenticacao",
+			Subsystem: "password_hash",
+			Name:      "queue_wait_seconds",
+			Help:      "Time password operations wait in the queue before a worker runs them.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 15), //nolint:gomnd
+		}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:exhaustruct
+			Namespace: "autenticacao",
+			Subsystem: "password_hash",
+			Name:      "duration_seconds",
+			Help:      "Time spent creating or comparing password hashes.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 15), //nolint:gomnd
+		}, []string{"operation"}),
+		rejected: prometheus.NewCounter(prometheus.CounterOpts{ //nolint:exhaustruct
+			Namespace: "autenticacao",
+			Subsystem: "password_hash",
+			Name:      "rejected_total",
+			Help:      "Password operations rejected because the queue was full.",
+		}),
+	}
+
+	pool.queued = prometheus.NewGaugeFunc(prometheus.GaugeOpts{ //nolint:exhaustruct
+		Namespace: "autenticacao",
+		Subsystem: "password_hash",
+		Name:      "queued",
+		Help:      "Password operations waiting in the queue.",
+	}, func() float64 { return float64(len(pool.jobs)) })
+
+	collectors := []prometheus.Collector{pool.wait, pool.duration, pool.rejected, pool.queued}
+
+	for _, collector := range collectors {
+		err := registerer.Register(collector)
+		if err != nil {
+			return nil, fmt.Errorf("error registering password pool metrics: %w", err)
+		}
+	}
+
+	for i := 0; i < concurrency; i++ {
+		go pool.worker()
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,229 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/thiago-felipe-99/autenticacao/core"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+func TestNewPasswordPool(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+
+	pool, err := core.NewPasswordPool(2, 10, registry)
+	require.NoError(t, err)
+	require.NotNil(t, pool)
+
+	_, err = core.NewPasswordPool(2, 10, registry)
+	require.ErrorContains(t, err, "error registering password pool metrics")
+
+	_, err = core.NewPasswordPool(0, 10, prometheus.NewRegistry())
+	require.ErrorIs(t, err, errs.ErrPoolConfiguration)
+
+	_, err = core.NewPasswordPool(1, -1, prometheus.NewRegistry())
+	require.ErrorIs(t, err, errs.ErrPoolConfiguration)
+}
+
+func gatherValue(t *testing.T, registry *prometheus.Registry, name string) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		metric := family.GetMetric()[0]
+
+		switch {
+		case metric.GetGauge() != nil:
+			return metric.GetGauge().GetValue()
+		case metric.GetCounter() != nil:
+			return metric.GetCounter().GetValue()
+		case metric.GetHistogram() != nil:
+			return float64(metric.GetHistogram().GetSampleCount())
+		}
+	}
+
+	return 0
+}
+
+func TestPasswordPool(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	concurrency, queue := 2, 3
+	registry := prometheus.NewRegistry()
+
+	pool, err := core.NewPasswordPool(concurrency, queue, registry)
+	require.NoError(t, err)
+
+	started := make(chan struct{}, concurrency)
+	release := make(chan struct{})
+	results := make(chan error, concurrency+queue)
+
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			results <- pool.Run("create", func() {
+				started <- struct{}{}
+				<-release
+			})
+		}()
+	}
+
+	for i := 0; i < concurrency; i++ {
+		<-started
+	}
+
+	for i := 0; i < queue; i++ {
+		go func() {
+			results <- pool.Run("compare", func() { <-release })
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		return gatherValue(t, registry, "autenticacao_password_hash_queued") == float64(queue)
+	}, time.Second, time.Millisecond)
+
+	executed := false
+
+	err = pool.Run("compare", func() { executed = true })
+	require.ErrorIs(t, err, errs.ErrPasswordPoolFull)
+	require.False(t, executed)
+	require.Equal(t, 1.0, gatherValue(t, registry, "autenticacao_password_hash_rejected_total"))
+
+	close(release)
+
+	for i := 0; i < concurrency+queue; i++ {
+		require.NoError(t, <-results)
+	}
+
+	err = pool.Run("compare", func() { executed = true })
+	require.NoError(t, err)
+	require.True(t, executed)
+
+	require.Equal(t, 0.0, gatherValue(t, registry, "autenticacao_password_hash_queued"))
+	require.Equal(
+		t,
+		float64(concurrency+queue+1),
+		gatherValue(t, registry, "autenticacao_password_hash_queue_wait_seconds"),
+	)
+	require.Equal(
+		t,
+		2,
+		testutil.CollectAndCount(registry, "autenticacao_password_hash_duration_seconds"),
+	)
+}
+
+func TestPasswordPoolUser(t *testing.T) {
+	t.Parallel()
+
+	pool, err := core.NewPasswordPool(1, 0, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	user := core.NewUser(nil, nil, model.Validate(), fastPasswordHash(), pool, 0, 0)
+
+	password := gofakeit.Password(true, true, true, true, true, 20)
+
+	hashed, err := fastPasswordHash().Create(password)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go func() {
+		work := func() {
+			close(started)
+			<-release
+		}
+
+		// the worker may not be waiting for jobs yet
+		for errors.Is(pool.Run("create", work), errs.ErrPasswordPoolFull) {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	<-started
+
+	match, err := user.EqualPassword(password, hashed)
+	require.ErrorIs(t, err, errs.ErrPasswordPoolFull)
+	require.False(t, match)
+
+	close(release)
+
+	require.Eventually(t, func() bool {
+		match, err := user.EqualPassword(password, hashed)
+
+		return err == nil && match
+	}, time.Second, time.Millisecond)
+}
+
+func TestPasswordPoolNil(t *testing.T) {
+	t.Parallel()
+
+	user := core.NewUser(nil, nil, model.Validate(), fastPasswordHash(), nil, 0, 0)
+
+	password := gofakeit.Password(true, true, true, true, true, 20)
+
+	hashed, err := fastPasswordHash().Create(password)
+	require.NoError(t, err)
+
+	match, err := user.EqualPassword(password, hashed)
+	require.NoError(t, err)
+	require.True(t, match)
+}
+
+func BenchmarkPasswordPool(b *testing.B) {
+	hash := core.DefaultPasswordHash()
+
+	pool, err := core.NewPasswordPool(4, b.N, prometheus.NewRegistry())
+	require.NoError(b, err)
+
+	user := core.NewUser(nil, nil, model.Validate(), hash, pool, 0, 0)
+
+	password := gofakeit.Password(true, true, true, true, true, 20)
+
+	hashed, err := hash.Create(password)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, err := user.EqualPassword(password, hashed)
+			if err != nil {
+				b.Error(err)
+			}
+		}
+	})
+
+}
+
+func TestPasswordPoolPanic(t *testing.T) {
+	t.Parallel()
+
+	pool, err := core.NewPasswordPool(1, 1, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	for _, pool := range []*core.PasswordPool{pool, nil} {
+		err = pool.Run("compare", func() { panic("malformed hash") })
+		require.ErrorIs(t, err, errs.ErrPasswordPanic)
+		require.ErrorContains(t, err, "malformed hash")
+
+		executed := false
+
+		err = pool.Run("compare", func() { executed = true })
+		require.NoError(t, err)
+		require.True(t, executed)
+	}
+}
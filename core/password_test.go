@@ -0,0 +1,281 @@
+package core_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+	"github.com/thiago-felipe-99/autenticacao/core"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func cheapPasswordHashes() []core.PasswordHash {
+	argon := core.DefaultPasswordHash()
+	argon.Argon2id.Memory = 1024
+	argon.Argon2id.Parallelism = 1
+
+	bcryptHash := core.DefaultPasswordHash()
+	bcryptHash.Algorithm = core.HashBcrypt
+	bcryptHash.BcryptCost = bcrypt.MinCost
+
+	scryptHash := core.DefaultPasswordHash()
+	scryptHash.Algorithm = core.HashScrypt
+	scryptHash.ScryptN = 1024
+
+	return []core.PasswordHash{argon, bcryptHash, scryptHash}
+}
+
+func TestPasswordHash(t *testing.T) {
+	t.Parallel()
+
+	for _, hash := range cheapPasswordHashes() {
+		hash := hash
+
+		t.Run(hash.Algorithm, func(t *testing.T) {
+			t.Parallel()
+
+			password := gofakeit.Password(true, true, true, true, true, 20)
+
+			hashed, err := hash.Create(password)
+			require.NoError(t, err)
+			require.NotContains(t, hashed, password)
+
+			match, err := hash.Compare(password, hashed)
+			require.NoError(t, err)
+			require.True(t, match)
+
+			match, err = hash.Compare(password+"wrong", hashed)
+			require.NoError(t, err)
+			require.False(t, match)
+
+			require.False(t, hash.NeedsRehash(hashed))
+			require.NoError(t, hash.Check(hashed))
+
+			for _, other := range cheapPasswordHashes() {
+				match, err := other.Compare(password, hashed)
+				require.NoError(t, err)
+				require.True(t, match)
+
+				require.Equal(t, other.Algorithm != hash.Algorithm, other.NeedsRehash(hashed))
+			}
+		})
+	}
+
+	t.Run("Salted", func(t *testing.T) {
+		t.Parallel()
+
+		for _, hash := range cheapPasswordHashes() {
+			password := gofakeit.Password(true, true, true, true, true, 20)
+
+			hashed1, err := hash.Create(password)
+			require.NoError(t, err)
+
+			hashed2, err := hash.Create(password)
+			require.NoError(t, err)
+
+			require.NotEqual(t, hashed1, hashed2)
+		}
+	})
+
+	t.Run("OutdatedParams", func(t *testing.T) {
+		t.Parallel()
+
+		hashes := cheapPasswordHashes()
+		password := gofakeit.Password(true, true, true, true, true, 20)
+
+		for _, hash := range hashes {
+			hashed, err := hash.Create(password)
+			require.NoError(t, err)
+
+			stronger := hash
+			stronger.Argon2id.Iterations++
+			stronger.BcryptCost++
+			stronger.ScryptN *= 2
+
+			require.True(t, stronger.NeedsRehash(hashed))
+
+			match, err := stronger.Compare(password, hashed)
+			require.NoError(t, err)
+			require.True(t, match)
+		}
+	})
+
+	t.Run("LegacySHA256", func(t *testing.T) {
+		t.Parallel()
+
+		password := gofakeit.Password(true, true, true, true, true, 20)
+		hashed := fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+
+		for _, hash := range cheapPasswordHashes() {
+			match, err := hash.Compare(password, hashed)
+			require.NoError(t, err)
+			require.True(t, match)
+
+			match, err = hash.Compare(password+"wrong", hashed)
+			require.NoError(t, err)
+			require.False(t, match)
+
+			require.True(t, hash.NeedsRehash(hashed))
+		}
+	})
+
+	t.Run("UnknownHash", func(t *testing.T) {
+		t.Parallel()
+
+		hash := core.DefaultPasswordHash()
+
+		match, err := hash.Compare(gofakeit.Name(), "invalid-hash")
+		require.ErrorIs(t, err, errs.ErrHashUnknown)
+		require.False(t, match)
+
+		require.True(t, hash.NeedsRehash("invalid-hash"))
+
+		hash.Algorithm = strings.ToUpper(gofakeit.LetterN(10))
+
+		hashed, err := hash.Create(gofakeit.Name())
+		require.ErrorIs(t, err, errs.ErrHashUnknown)
+		require.Empty(t, hashed)
+	})
+}
+
+//nolint:lll
+const (
+	foreignPassword          = "correct horse battery staple"
+	djangoPBKDF2SHA256       = "pbkdf2_sha256$1000$seasalt$3xmXbyk2QpiyNcnoBbzRPwEBsYPTbDlRdtmLyBvQltA="
+	djangoPBKDF2SHA1         = "pbkdf2_sha1$1000$seasalt$9iRYj0as1r5j+cCBxB+HMlxkyr4="
+	keycloakSecretSHA256     = `{"value":"yqSq2SygY1sB4EcH9f2FG0JTMES+wqLsOT5YmiRBplLYbawB4lwjdPCI3Ns1d3pRT9/+PGa1sFXN8qwjZx9S8g==","salt":"MDEyMzQ1Njc4OWFiY2RlZg==","additionalParameters":{}}`
+	keycloakCredentialSHA256 = `{"hashIterations":1000,"algorithm":"pbkdf2-sha256","additionalParameters":{}}`
+	keycloakSecretSHA512     = `{"value":"5bTW2oeyDJyGJPcmEr+mRDE11ghpozWrDGnSNhRXo2ZCu0KHRDiUMAVEGzLJC+oDhRoeIja9J1iLIv9ptDJMfQ==","salt":"MDEyMzQ1Njc4OWFiY2RlZg==","additionalParameters":{}}`
+	keycloakCredentialSHA512 = `{"hashIterations":1000,"algorithm":"pbkdf2-sha512","additionalParameters":{}}`
+)
+
+func foreignHashes(t *testing.T) []string {
+	t.Helper()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(foreignPassword), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	bcryptSHA256Hash, err := bcrypt.GenerateFromPassword(
+		[]byte(fmt.Sprintf("%x", sha256.Sum256([]byte(foreignPassword)))),
+		bcrypt.MinCost,
+	)
+	require.NoError(t, err)
+
+	keycloakSHA256, err := core.KeycloakHash(keycloakSecretSHA256, keycloakCredentialSHA256)
+	require.NoError(t, err)
+
+	keycloakSHA512, err := core.KeycloakHash(keycloakSecretSHA512, keycloakCredentialSHA512)
+	require.NoError(t, err)
+
+	return []string{
+		djangoPBKDF2SHA256,
+		djangoPBKDF2SHA1,
+		"bcrypt$" + string(bcryptHash),
+		"bcrypt_sha256$" + string(bcryptSHA256Hash),
+		keycloakSHA256,
+		keycloakSHA512,
+	}
+}
+
+// expensiveHashes returns valid hashes with parameters above the limits.
+func expensiveHashes(t *testing.T) []string {
+	t.Helper()
+
+	cheap := cheapPasswordHashes()
+
+	argon, err := cheap[0].Create(foreignPassword)
+	require.NoError(t, err)
+
+	bcryptHash, err := cheap[1].Create(foreignPassword)
+	require.NoError(t, err)
+
+	scryptHash, err := cheap[2].Create(foreignPassword)
+	require.NoError(t, err)
+
+	keycloak, err := core.KeycloakHash(
+		keycloakSecretSHA256,
+		`{"hashIterations":100000000,"algorithm":"pbkdf2-sha256","additionalParameters":{}}`,
+	)
+	require.NoError(t, err)
+
+	return []string{
+		strings.Replace(argon, "m=1024", "m=4194304", 1),
+		strings.Replace(bcryptHash, "$04$", "$31$", 1),
+		"bcrypt$" + strings.Replace(bcryptHash, "$04$", "$31$", 1),
+		strings.Replace(scryptHash, "n=1024", "n=4194304", 1),
+		strings.Replace(scryptHash, "r=8", "r=1024", 1),
+		strings.Replace(djangoPBKDF2SHA256, "$1000$", "$100000000$", 1),
+		keycloak,
+	}
+}
+
+func TestPasswordHashLimits(t *testing.T) {
+	t.Parallel()
+
+	hash := core.DefaultPasswordHash()
+
+	for _, expensive := range expensiveHashes(t) {
+		require.True(t, hash.Supports(expensive), expensive)
+		require.ErrorIs(t, hash.Check(expensive), errs.ErrHashParameters, expensive)
+
+		match, err := hash.Compare(foreignPassword, expensive)
+		require.ErrorIs(t, err, errs.ErrHashParameters, expensive)
+		require.False(t, match)
+	}
+}
+
+func TestPasswordHashForeign(t *testing.T) {
+	t.Parallel()
+
+	hash := core.DefaultPasswordHash()
+
+	for _, foreign := range foreignHashes(t) {
+		require.True(t, hash.Supports(foreign))
+		require.True(t, hash.NeedsRehash(foreign))
+		require.NoError(t, hash.Check(foreign))
+
+		match, err := hash.Compare(foreignPassword, foreign)
+		require.NoError(t, err)
+		require.True(t, match, foreign)
+
+		match, err = hash.Compare(foreignPassword+"wrong", foreign)
+		require.NoError(t, err)
+		require.False(t, match, foreign)
+	}
+
+	t.Run("InvalidKeycloak", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := core.KeycloakHash("invalid-json", keycloakCredentialSHA256)
+		require.ErrorIs(t, err, errs.ErrHashUnknown)
+
+		_, err = core.KeycloakHash(keycloakSecretSHA256, "invalid-json")
+		require.ErrorIs(t, err, errs.ErrHashUnknown)
+
+		_, err = core.KeycloakHash(
+			keycloakSecretSHA256,
+			`{"hashIterations":1000,"algorithm":"argon2"}`,
+		)
+		require.ErrorIs(t, err, errs.ErrHashUnknown)
+	})
+
+	t.Run("InvalidDjango", func(t *testing.T) {
+		t.Parallel()
+
+		invalids := []string{
+			"pbkdf2_sha256$1000$seasalt",
+			"pbkdf2_sha256$invalid$seasalt$3xmXbyk2QpiyNcnoBbzRPwEBsYPTbDlRdtmLyBvQltA=",
+			"pbkdf2_sha256$1000$seasalt$invalid-base64",
+		}
+
+		for _, invalid := range invalids {
+			match, err := hash.Compare(foreignPassword, invalid)
+			require.Error(t, err)
+			require.False(t, match)
+		}
+	})
+}
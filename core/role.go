@@ -3,6 +3,7 @@ package core
 import (
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -29,10 +30,22 @@ func (r *Role) GetByName(name string) (model.Role, error) {
 	return role, nil
 }
 
-func (r *Role) GetAll(paginate int, qt int) ([]model.Role, error) {
-	roles, err := r.database.GetAll(paginate, qt)
+func (r *Role) GetAll(
+	deleted model.Deleted,
+	pagination model.Pagination,
+) (model.Page[model.Role], error) {
+	roles, err := r.database.GetAll(deleted, pagination)
 	if err != nil {
-		return model.EmptyRoles, fmt.Errorf("error getting role from database: %w", err)
+		return model.EmptyPage[model.Role](), fmt.Errorf("error getting role from database: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *Role) GetByNames(names []string) ([]model.Role, error) {
+	roles, err := r.database.GetByNames(names)
+	if err != nil {
+		return model.EmptyRoles, fmt.Errorf("error getting roles from database: %w", err)
 	}
 
 	return roles, nil
@@ -53,15 +66,6 @@ func (r *Role) Create(createdBy model.ID, partial model.RolePartial) error {
 		return err
 	}
 
-	_, err = r.GetByName(partial.Name)
-	if err != nil && !errors.Is(err, errs.ErrRoleNotFound) {
-		return err
-	}
-
-	if err == nil {
-		return errs.ErrRoleAlreadyExist
-	}
-
 	role := model.Role{
 		Name:      partial.Name,
 		CreatedAt: time.Now(),
@@ -72,6 +76,10 @@ func (r *Role) Create(createdBy model.ID, partial model.RolePartial) error {
 
 	err = r.database.Create(role)
 	if err != nil {
+		if errors.Is(err, errs.ErrRoleAlreadyExist) {
+			return errs.ErrRoleAlreadyExist
+		}
+
 		return fmt.Errorf("error creating role in the database: %w", err)
 	}
 
@@ -92,6 +100,32 @@ func (r *Role) Delete(deleteBy model.ID, name string) error {
 	return nil
 }
 
+// Restore undoes the soft delete of a role, the role is not given back to the
+// users that had it.
+func (r *Role) Restore(restoredBy model.ID, name string) error {
+	_, err := r.GetByName(name)
+	if err == nil {
+		return errs.ErrRoleNotDeleted
+	}
+
+	if !errors.Is(err, errs.ErrRoleNotFound) {
+		return err
+	}
+
+	err = r.database.Restore(name)
+	if err != nil {
+		if errors.Is(err, errs.ErrRoleNotFound) {
+			return errs.ErrRoleNotFound
+		}
+
+		return fmt.Errorf("error restoring role in database: %w", err)
+	}
+
+	log.Printf("[INFO] - User '%s' restored role '%s'", restoredBy, name)
+
+	return nil
+}
+
 func NewRole(database data.Role, validate *validator.Validate) *Role {
 	return &Role{
 		database: database,
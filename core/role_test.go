@@ -92,6 +92,16 @@ func TestRoleCreate(t *testing.T) {
 			require.NoError(t, err)
 		})
 	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		input := model.RolePartial{Name: gofakeit.Name()}
+
+		requireOneWins(t, 20, errs.ErrRoleAlreadyExist, func() error {
+			return role.Create(model.NewID(), input)
+		})
+	})
 }
 
 func TestRoleGet(t *testing.T) {
@@ -126,7 +136,7 @@ func TestRoleGet(t *testing.T) {
 	t.Run("GetAll", func(t *testing.T) {
 		t.Parallel()
 
-		rolesdb, err := role.GetAll(0, qtRoles)
+		rolesdb, err := items(role.GetAll(model.ExcludeDeleted, model.FirstPage(qtRoles)))
 		require.NoError(t, err)
 
 		require.Equal(t, qtRoles, len(rolesdb))
@@ -139,12 +149,22 @@ func TestRoleGet(t *testing.T) {
 		}
 	})
 
-	t.Run("GetAll/NoResult", func(t *testing.T) {
+	t.Run("GetAll/LastPage", func(t *testing.T) {
 		t.Parallel()
 
-		rolesdb, err := role.GetAll(qtRoles, qtRoles)
+		page, err := role.GetAll(model.ExcludeDeleted, model.FirstPage(qtRoles-1))
 		require.NoError(t, err)
-		require.Equal(t, model.EmptyRoles, rolesdb)
+		require.Len(t, page.Items, qtRoles-1)
+		require.NotEmpty(t, page.NextCursor)
+		require.Nil(t, page.Total)
+
+		pagination := model.Pagination{Cursor: page.NextCursor, Qt: qtRoles, Total: true}
+
+		page, err = role.GetAll(model.ExcludeDeleted, pagination)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		require.Empty(t, page.NextCursor)
+		require.Equal(t, qtRoles, *page.Total)
 	})
 }
 
@@ -183,7 +203,11 @@ func TestRoleDelete(t *testing.T) {
 	t.Run("GetAll", func(t *testing.T) {
 		t.Parallel()
 
-		rolesdb, err := role.GetAll(0, qtRoles)
+		rolesdb, err := items(role.GetAll(model.ExcludeDeleted, model.FirstPage(qtRoles)))
+		require.NoError(t, err)
+		require.Equal(t, model.EmptyRoles, rolesdb)
+
+		rolesdb, err = items(role.GetAll(model.IncludeDeleted, model.FirstPage(qtRoles)))
 		require.NoError(t, err)
 
 		require.Equal(t, qtRoles, len(rolesdb))
@@ -208,6 +232,33 @@ func TestRoleDelete(t *testing.T) {
 	})
 }
 
+func TestRoleRestore(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "role_restore")
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+
+	createdBy, roleTemp := createTempRole(t, role, db)
+
+	err := role.Restore(model.NewID(), roleTemp.Name)
+	require.ErrorIs(t, err, errs.ErrRoleNotDeleted)
+
+	err = role.Delete(model.NewID(), roleTemp.Name)
+	require.NoError(t, err)
+
+	err = role.Restore(model.NewID(), roleTemp.Name)
+	require.NoError(t, err)
+
+	roledb, err := role.GetByName(roleTemp.Name)
+	require.NoError(t, err)
+	require.Equal(t, createdBy, roledb.CreatedBy)
+	require.True(t, time.Time{}.Equal(roledb.DeletedAt))
+	require.Equal(t, model.EmptyID, roledb.DeletedBy)
+
+	err = role.Restore(model.NewID(), gofakeit.Name())
+	require.ErrorIs(t, err, errs.ErrRoleNotFound)
+}
+
 func TestRoleWrongDB(t *testing.T) {
 	t.Parallel()
 
@@ -217,7 +268,7 @@ func TestRoleWrongDB(t *testing.T) {
 	err := role.Create(model.NewID(), model.RolePartial{Name: gofakeit.Name()})
 	require.ErrorContains(t, err, "no such host")
 
-	roles, err := role.GetAll(0, 100)
+	roles, err := items(role.GetAll(model.ExcludeDeleted, model.FirstPage(100)))
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, model.EmptyRoles, roles)
 
@@ -231,4 +282,7 @@ func TestRoleWrongDB(t *testing.T) {
 
 	err = role.Delete(model.NewID(), gofakeit.Name())
 	require.ErrorContains(t, err, "no such host")
+
+	err = role.Restore(model.NewID(), gofakeit.Name())
+	require.ErrorContains(t, err, "no such host")
 }
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/thiago-felipe-99/autenticacao/data"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+const (
+	tokenPrefix = "pat_"
+	tokenSize   = 32
+)
+
+// hashToken hashes a token secret. A fast hash is enough because the secret is
+// random, unlike passwords, and it allows finding the token by its hash.
+func hashToken(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// Token manages personal access tokens, long lived credentials used by scripts
+// with a subset of the user roles.
+type Token struct {
+	database data.Token
+	user     *User
+	validate *validator.Validate
+}
+
+func (t *Token) GetByUserID(
+	userID model.ID,
+	pagination model.Pagination,
+) (model.Page[model.Token], error) {
+	_, err := t.user.GetByID(userID)
+	if err != nil {
+		return model.EmptyPage[model.Token](), err
+	}
+
+	tokens, err := t.database.GetByUserID(userID, pagination)
+	if err != nil {
+		return model.EmptyPage[model.Token](),
+			fmt.Errorf("error getting tokens from database: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Create a token for the user, the secret is only returned here and it is
+// stored hashed. Only the user can create their tokens.
+func (t *Token) Create(
+	createdBy model.ID,
+	userID model.ID,
+	partial model.TokenPartial,
+) (model.TokenCreated, error) {
+	if createdBy != userID {
+		return model.TokenCreated{}, errs.ErrTokenNotOwner
+	}
+
+	err := Validate(t.validate, partial)
+	if err != nil {
+		return model.TokenCreated{}, err
+	}
+
+	user, err := t.user.GetByID(userID)
+	if err != nil {
+		return model.TokenCreated{}, err
+	}
+
+	roles := make([]string, 0, len(partial.Roles))
+
+	for _, role := range partial.Roles {
+		if !slices.Contains(user.Roles, role) {
+			return model.TokenCreated{}, errs.ErrTokenRoles
+		}
+
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+
+	now := time.Now()
+
+	if !partial.Expires.IsZero() && !partial.Expires.After(now) {
+		return model.TokenCreated{}, errs.ErrTokenExpired
+	}
+
+	random := make([]byte, tokenSize)
+
+	_, err = rand.Read(random)
+	if err != nil {
+		return model.TokenCreated{}, fmt.Errorf("error creating token secret: %w", err)
+	}
+
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	token := model.Token{
+		ID:        model.NewID(),
+		UserID:    user.ID,
+		Name:      partial.Name,
+		Hash:      hashToken(secret),
+		Roles:     roles,
+		Expires:   partial.Expires,
+		CreatedAt: now,
+		CreatedBy: createdBy,
+		DeletedAt: time.Time{},
+		DeletedBy: model.EmptyID,
+	}
+
+	err = t.database.Create(token)
+	if err != nil {
+		return model.TokenCreated{}, fmt.Errorf("error creating token in the database: %w", err)
+	}
+
+	return model.TokenCreated{Token: token, Secret: secret}, nil
+}
+
+// Delete revokes a token of the user.
+func (t *Token) Delete(deletedBy model.ID, userID model.ID, id model.ID) error {
+	token, err := t.database.GetByID(id)
+	if err != nil {
+		if errors.Is(err, errs.ErrTokenNotFound) {
+			return errs.ErrTokenNotFound
+		}
+
+		return fmt.Errorf("error getting token from database: %w", err)
+	}
+
+	if token.UserID != userID {
+		return errs.ErrTokenNotFound
+	}
+
+	err = t.database.Delete(id, time.Now(), deletedBy)
+	if err != nil {
+		return fmt.Errorf("error deleting token from database: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByUserID revokes all the tokens of the user.
+func (t *Token) DeleteByUserID(deletedBy model.ID, userID model.ID) error {
+	err := t.database.DeleteByUserID(userID, time.Now(), deletedBy)
+	if err != nil {
+		return fmt.Errorf("error deleting tokens from database: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate returns the token of the secret when it is valid. The token
+// roles are limited to the current user roles, so removing a role from the user
+// also removes it from their tokens, and the token is restricted while the user
+// password is expired.
+func (t *Token) Authenticate(secret string) (model.TokenAuthenticated, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return model.TokenAuthenticated{}, errs.ErrTokenNotFound
+	}
+
+	token, err := t.database.GetByHash(hashToken(secret))
+	if err != nil {
+		if errors.Is(err, errs.ErrTokenNotFound) {
+			return model.TokenAuthenticated{}, errs.ErrTokenNotFound
+		}
+
+		return model.TokenAuthenticated{}, fmt.Errorf("error getting token from database: %w", err)
+	}
+
+	if token.Expired(time.Now()) {
+		return model.TokenAuthenticated{}, errs.ErrTokenNotFound
+	}
+
+	user, err := t.user.GetByID(token.UserID)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return model.TokenAuthenticated{}, errs.ErrTokenNotFound
+		}
+
+		return model.TokenAuthenticated{}, err
+	}
+
+	token.Roles = slices.DeleteFunc(token.Roles, func(role string) bool {
+		return !slices.Contains(user.Roles, role)
+	})
+
+	return model.TokenAuthenticated{Token: token, Restricted: t.user.PasswordExpired(user)}, nil
+}
+
+func NewToken(database data.Token, user *User, validate *validator.Validate) *Token {
+	return &Token{
+		database: database,
+		user:     user,
+		validate: validate,
+	}
+}
@@ -0,0 +1,151 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+	"github.com/thiago-felipe-99/autenticacao/core"
+	"github.com/thiago-felipe-99/autenticacao/data"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+func TestToken(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "token")
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	token := core.NewToken(data.NewTokenSQL(db), user, model.Validate())
+
+	_, roleA := createTempRole(t, role, db)
+	_, roleB := createTempRole(t, role, db)
+
+	userID, err := user.Create(model.EmptyID, model.UserPartial{ //nolint:exhaustruct
+		Name:     gofakeit.Name(),
+		Username: gofakeit.Username(),
+		Email:    gofakeit.Email(),
+		Password: gofakeit.Password(true, true, true, true, true, 20),
+		Roles:    []string{roleA.Name, roleB.Name},
+	})
+	require.NoError(t, err)
+
+	t.Run("InvalidInputs", func(t *testing.T) {
+		_, err := token.Create(userID, userID, model.TokenPartial{}) //nolint:exhaustruct
+		require.ErrorAs(t, err, &core.InvalidError{})
+
+		_, err = token.Create(userID, model.NewID(), model.TokenPartial{Name: "ci"}) //nolint:exhaustruct
+		require.ErrorIs(t, err, errs.ErrUserNotFound)
+
+		_, err = token.Create(userID, userID, model.TokenPartial{ //nolint:exhaustruct
+			Name:  "ci",
+			Roles: []string{gofakeit.Name()},
+		})
+		require.ErrorIs(t, err, errs.ErrTokenRoles)
+
+		_, err = token.Create(userID, userID, model.TokenPartial{
+			Name:    "ci",
+			Expires: time.Now().Add(-time.Minute),
+			Roles:   []string{roleA.Name},
+		})
+		require.ErrorIs(t, err, errs.ErrTokenExpired)
+
+		_, err = token.Authenticate("invalid-token")
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		created, err := token.Create(userID, userID, model.TokenPartial{ //nolint:exhaustruct
+			Name:  "ci",
+			Roles: []string{roleA.Name, roleA.Name},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, created.Secret)
+		require.NotContains(t, created.Hash, created.Secret)
+		require.Equal(t, []string{roleA.Name}, created.Roles)
+
+		found, err := token.Authenticate(created.Secret)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, found.ID)
+		require.Equal(t, userID, found.UserID)
+		require.Equal(t, []string{roleA.Name}, found.Roles)
+		require.False(t, found.Restricted)
+
+		_, err = token.Authenticate(strings.ToUpper(created.Secret))
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+
+		tokens, err := items(token.GetByUserID(userID, model.FirstPage(10)))
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+
+		err = token.Delete(userID, model.NewID(), created.ID)
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+
+		err = token.Delete(userID, userID, created.ID)
+		require.NoError(t, err)
+
+		_, err = token.Authenticate(created.Secret)
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+	})
+
+	t.Run("NotOwner", func(t *testing.T) {
+		_, err := token.Create(model.NewID(), userID, model.TokenPartial{ //nolint:exhaustruct
+			Name:  "other",
+			Roles: []string{roleA.Name},
+		})
+		require.ErrorIs(t, err, errs.ErrTokenNotOwner)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		created, err := token.Create(userID, userID, model.TokenPartial{
+			Name:    "expires",
+			Expires: time.Now().Add(time.Second),
+			Roles:   []string{roleB.Name},
+		})
+		require.NoError(t, err)
+
+		_, err = token.Authenticate(created.Secret)
+		require.NoError(t, err)
+
+		time.Sleep(time.Second)
+
+		_, err = token.Authenticate(created.Secret)
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+	})
+
+	t.Run("RoleRemoved", func(t *testing.T) {
+		created, err := token.Create(userID, userID, model.TokenPartial{ //nolint:exhaustruct
+			Name:  "roles",
+			Roles: []string{roleA.Name, roleB.Name},
+		})
+		require.NoError(t, err)
+
+		update := model.UserUpdate{Roles: []string{roleB.Name}} //nolint:exhaustruct
+		err = user.Update(userID, model.AnyVersion, update)
+		require.NoError(t, err)
+
+		found, err := token.Authenticate(created.Secret)
+		require.NoError(t, err)
+		require.Equal(t, []string{roleB.Name}, found.Roles)
+	})
+
+	t.Run("PasswordExpired", func(t *testing.T) {
+		created, err := token.Create(userID, userID, model.TokenPartial{ //nolint:exhaustruct
+			Name:  "restricted",
+			Roles: []string{roleB.Name},
+		})
+		require.NoError(t, err)
+
+		mustChange := true
+		update := model.UserUpdate{MustChangePassword: &mustChange} //nolint:exhaustruct
+		err = user.Update(userID, model.AnyVersion, update)
+		require.NoError(t, err)
+
+		found, err := token.Authenticate(created.Secret)
+		require.NoError(t, err)
+		require.True(t, found.Restricted)
+	})
+}
@@ -1,25 +1,28 @@
 package core
 
 import (
-	"crypto/sha256"
 	"errors"
 	"fmt"
+	"log"
 	"slices"
+	"strings"
 	"time"
 
-	"github.com/alexedwards/argon2id"
 	"github.com/go-playground/validator/v10"
 	"github.com/thiago-felipe-99/autenticacao/data"
 	"github.com/thiago-felipe-99/autenticacao/errs"
 	"github.com/thiago-felipe-99/autenticacao/model"
+	"golang.org/x/text/unicode/norm"
 )
 
 type User struct {
-	database    data.User
-	role        *Role
-	validate    *validator.Validate
-	argon2id    argon2id.Params
-	argonEnable bool
+	database data.User
+	role     *Role
+	validate *validator.Validate
+	hash     PasswordHash
+	pool     *PasswordPool
+	history  int
+	maxAge   time.Duration
 }
 
 func (u *User) GetByID(id model.ID) (model.User, error) {
@@ -36,7 +39,7 @@ func (u *User) GetByID(id model.ID) (model.User, error) {
 }
 
 func (u *User) GetByUsername(username string) (model.User, error) {
-	user, err := u.database.GetByUsername(username)
+	user, err := u.database.GetByUsername(normalizeUsername(username))
 	if err != nil {
 		if errors.Is(err, errs.ErrUserNotFound) {
 			return model.EmptyUser, errs.ErrUserNotFound
@@ -49,7 +52,7 @@ func (u *User) GetByUsername(username string) (model.User, error) {
 }
 
 func (u *User) GetByEmail(email string) (model.User, error) {
-	user, err := u.database.GetByEmail(email)
+	user, err := u.database.GetByEmail(normalizeEmail(email))
 	if err != nil {
 		if errors.Is(err, errs.ErrUserNotFound) {
 			return model.EmptyUser, errs.ErrUserNotFound
@@ -61,67 +64,199 @@ func (u *User) GetByEmail(email string) (model.User, error) {
 	return user, nil
 }
 
-func (u *User) GetAll(paginate int, qt int) ([]model.User, error) {
-	users, err := u.database.GetAll(paginate, qt)
+func (u *User) GetAll(
+	deleted model.Deleted,
+	pagination model.Pagination,
+) (model.Page[model.User], error) {
+	users, err := u.database.GetAll(deleted, pagination)
 	if err != nil {
-		return model.EmptyUsers, fmt.Errorf("error on getting user from database: %w", err)
+		return model.EmptyPage[model.User](),
+			fmt.Errorf("error on getting user from database: %w", err)
 	}
 
 	return users, nil
 }
 
-func (u *User) GetByRole(roles []string, paginate int, qt int) ([]model.User, error) {
+func (u *User) Search(
+	filter model.UserFilter,
+	pagination model.Pagination,
+) (model.Page[model.User], error) {
+	for _, sort := range filter.Sort {
+		if !slices.Contains(model.UserSortFields, sort.Field) {
+			return model.EmptyPage[model.User](),
+				fmt.Errorf("%w: %s", errs.ErrInvalidSort, sort.Field)
+		}
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() &&
+		filter.CreatedFrom.After(filter.CreatedTo) {
+		return model.EmptyPage[model.User](),
+			fmt.Errorf("%w: createdFrom is after createdTo", errs.ErrInvalidFilter)
+	}
+
+	users, err := u.database.Search(filter, pagination)
+	if err != nil {
+		return model.EmptyPage[model.User](),
+			fmt.Errorf("error on searching users in database: %w", err)
+	}
+
+	return users, nil
+}
+
+func (u *User) GetByRole(
+	roles []string,
+	deleted model.Deleted,
+	pagination model.Pagination,
+) (model.Page[model.User], error) {
 	exist, err := u.role.Exist(roles)
 	if err != nil {
-		return model.EmptyUsers, err
+		return model.EmptyPage[model.User](), err
 	}
 
 	if !exist {
-		return model.EmptyUsers, errs.ErrRoleNotFound
+		return model.EmptyPage[model.User](), errs.ErrRoleNotFound
 	}
 
-	users, err := u.database.GetByRoles(roles, paginate, qt)
+	users, err := u.database.GetByRoles(roles, deleted, pagination)
 	if err != nil {
-		return model.EmptyUsers, fmt.Errorf("error on getting user from database: %w", err)
+		return model.EmptyPage[model.User](),
+			fmt.Errorf("error on getting user from database: %w", err)
 	}
 
 	return users, nil
 }
 
+// Expand gets the resources referenced by the users for each expansion, with
+// a single batched query per expansion.
+func (u *User) Expand(users []model.User, expand []string) (model.UserExpansions, error) {
+	for _, expansion := range expand {
+		if expansion != model.ExpandRoles && expansion != model.ExpandCreatedBy {
+			return model.EmptyUserExpansions, fmt.Errorf("%w: %s", errs.ErrInvalidExpand, expansion)
+		}
+	}
+
+	expansions := model.UserExpansions{Roles: nil, Users: nil}
+
+	for _, expansion := range expand {
+		switch expansion {
+		case model.ExpandRoles:
+			names := []string{}
+
+			for _, user := range users {
+				for _, role := range user.Roles {
+					if !slices.Contains(names, role) {
+						names = append(names, role)
+					}
+				}
+			}
+
+			roles, err := u.role.GetByNames(names)
+			if err != nil {
+				return model.EmptyUserExpansions, err
+			}
+
+			expansions.Roles = make(map[string]model.Role, len(roles))
+			for _, role := range roles {
+				expansions.Roles[role.Name] = role
+			}
+
+		case model.ExpandCreatedBy:
+			ids := []model.ID{}
+
+			for _, user := range users {
+				if !slices.Contains(ids, user.CreatedBy) {
+					ids = append(ids, user.CreatedBy)
+				}
+			}
+
+			summaries, err := u.database.GetSummaries(ids)
+			if err != nil {
+				return model.EmptyUserExpansions, fmt.Errorf(
+					"error getting users summaries from database: %w",
+					err,
+				)
+			}
+
+			expansions.Users = make(map[model.ID]model.UserSummary, len(summaries))
+			for _, summary := range summaries {
+				expansions.Users[summary.ID] = summary
+			}
+		}
+	}
+
+	return expansions, nil
+}
+
 func (u *User) Create(createdBy model.ID, partial model.UserPartial) (model.ID, error) {
+	partial.Username = normalizeUsername(partial.Username)
+	partial.Email = normalizeEmail(partial.Email)
+
 	err := Validate(u.validate, partial)
 	if err != nil {
 		return model.EmptyID, err
 	}
 
-	exist, err := u.role.Exist(partial.Roles)
+	return u.create(createdBy, partial, func() (string, error) {
+		return u.createHash(partial.Password)
+	})
+}
+
+// Import creates a user with a password hash computed by another system. The
+// hash is kept as it is and replaced by a native one on the first login.
+func (u *User) Import(createdBy model.ID, partial model.UserImport) (model.ID, error) {
+	partial.Username = normalizeUsername(partial.Username)
+	partial.Email = normalizeEmail(partial.Email)
+
+	err := Validate(u.validate, partial)
 	if err != nil {
 		return model.EmptyID, err
 	}
 
-	if !exist {
-		return model.EmptyID, errs.ErrRoleNotFound
+	hash := partial.PasswordHash
+
+	if partial.Keycloak != nil {
+		hash, err = KeycloakHash(partial.Keycloak.SecretData, partial.Keycloak.CredentialData)
+		if err != nil {
+			return model.EmptyID, err
+		}
 	}
 
-	_, err = u.GetByUsername(partial.Username)
-	if err != nil && !errors.Is(err, errs.ErrUserNotFound) {
-		return model.EmptyID, err
+	err = u.hash.Check(hash)
+	if err != nil {
+		if errors.Is(err, errs.ErrHashParameters) {
+			return model.EmptyID, errs.ErrHashParameters
+		}
+
+		return model.EmptyID, errs.ErrHashUnknown
 	}
 
-	if err == nil {
-		return model.EmptyID, errs.ErrUsernameAlreadyExist
+	user := model.UserPartial{
+		Name:               partial.Name,
+		Username:           partial.Username,
+		Email:              partial.Email,
+		Password:           "",
+		Roles:              partial.Roles,
+		MustChangePassword: partial.MustChangePassword,
 	}
 
-	_, err = u.GetByEmail(partial.Email)
-	if err != nil && !errors.Is(err, errs.ErrUserNotFound) {
+	return u.create(createdBy, user, func() (string, error) { return hash, nil })
+}
+
+func (u *User) create(
+	createdBy model.ID,
+	partial model.UserPartial,
+	createHash func() (string, error),
+) (model.ID, error) {
+	exist, err := u.role.Exist(partial.Roles)
+	if err != nil {
 		return model.EmptyID, err
 	}
 
-	if err == nil {
-		return model.EmptyID, errs.ErrEmailAlreadyExist
+	if !exist {
+		return model.EmptyID, errs.ErrRoleNotFound
 	}
 
-	hash, err := u.createHash(partial.Password)
+	hash, err := createHash()
 	if err != nil {
 		return model.EmptyID, err
 	}
@@ -134,28 +269,62 @@ func (u *User) Create(createdBy model.ID, partial model.UserPartial) (model.ID,
 	}
 
 	user := model.User{
-		ID:        model.NewID(),
-		Name:      partial.Name,
-		Username:  partial.Username,
-		Email:     partial.Email,
-		Password:  hash,
-		Roles:     partial.Roles,
-		IsActive:  true,
-		CreatedAt: time.Now(),
-		CreatedBy: createdBy,
-		DeletedAt: time.Time{},
-		DeletedBy: model.EmptyID,
+		ID:                 model.NewID(),
+		Name:               partial.Name,
+		Username:           partial.Username,
+		Email:              partial.Email,
+		Password:           hash,
+		Roles:              partial.Roles,
+		IsActive:           true,
+		PasswordChangedAt:  time.Now(),
+		MustChangePassword: partial.MustChangePassword,
+		CreatedAt:          time.Now(),
+		CreatedBy:          createdBy,
+		DeletedAt:          time.Time{},
+		DeletedBy:          model.EmptyID,
 	}
 
 	err = u.database.Create(user)
 	if err != nil {
+		if existErr := alreadyExist(err); existErr != nil {
+			return model.EmptyID, existErr
+		}
+
 		return model.EmptyID, fmt.Errorf("error creating user in the database: %w", err)
 	}
 
 	return user.ID, nil
 }
 
-func (u *User) Update(userID model.ID, partial model.UserUpdate) error {
+// anyVersionAttempts is how many times an update at model.AnyVersion is tried
+// when concurrent updates keep changing the user.
+const anyVersionAttempts = 5
+
+// Update changes the user when it is still at the version, which is the one the
+// changes were based on, or at any version with model.AnyVersion. Concurrent
+// updates of the same version fail with errs.ErrUserVersionMismatch, but one.
+// Updates at model.AnyVersion are applied again over the concurrent changes.
+func (u *User) Update(userID model.ID, version int, partial model.UserUpdate) error {
+	if version != model.AnyVersion {
+		return u.update(userID, version, partial)
+	}
+
+	var err error
+
+	for attempt := 0; attempt < anyVersionAttempts; attempt++ {
+		err = u.update(userID, version, partial)
+		if !errors.Is(err, errs.ErrUserVersionMismatch) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (u *User) update(userID model.ID, version int, partial model.UserUpdate) error {
+	partial.Username = normalizeUsername(partial.Username)
+	partial.Email = normalizeEmail(partial.Email)
+
 	err := Validate(u.validate, partial)
 	if err != nil {
 		return err
@@ -166,43 +335,36 @@ func (u *User) Update(userID model.ID, partial model.UserUpdate) error {
 		return err
 	}
 
+	if version != model.AnyVersion && version != user.Version {
+		return errs.ErrUserVersionMismatch
+	}
+
 	if partial.Name != "" {
 		user.Name = partial.Name
 	}
 
 	if partial.Username != "" {
-		_, err = u.GetByUsername(partial.Username)
-		if err != nil && !errors.Is(err, errs.ErrUserNotFound) {
-			return err
-		}
-
-		if err == nil {
-			return errs.ErrUsernameAlreadyExist
-		}
-
 		user.Username = partial.Username
 	}
 
 	if partial.Email != "" {
-		_, err = u.GetByEmail(partial.Email)
-		if err != nil && !errors.Is(err, errs.ErrUserNotFound) {
-			return err
-		}
-
-		if err == nil {
-			return errs.ErrEmailAlreadyExist
-		}
-
 		user.Email = partial.Email
 	}
 
 	if partial.Password != "" {
+		err = u.checkPasswordHistory(user, partial.Password)
+		if err != nil {
+			return err
+		}
+
 		hash, err := u.createHash(partial.Password)
 		if err != nil {
 			return err
 		}
 
 		user.Password = hash
+		user.PasswordChangedAt = time.Now()
+		user.MustChangePassword = false
 	}
 
 	if partial.Roles != nil {
@@ -222,14 +384,72 @@ func (u *User) Update(userID model.ID, partial model.UserUpdate) error {
 		user.IsActive = *partial.IsActive
 	}
 
+	if partial.MustChangePassword != nil {
+		user.MustChangePassword = *partial.MustChangePassword
+	}
+
 	err = u.database.Update(user)
 	if err != nil {
-		return fmt.Errorf("error creating user in the database: %w", err)
+		if errors.Is(err, errs.ErrUserVersionMismatch) {
+			return errs.ErrUserVersionMismatch
+		}
+
+		if existErr := alreadyExist(err); existErr != nil {
+			return existErr
+		}
+
+		return fmt.Errorf("error updating user in the database: %w", err)
 	}
 
 	return nil
 }
 
+// UpdateSelf updates the fields users can change on their own account, roles
+// and status are only changed by Update.
+func (u *User) UpdateSelf(userID model.ID, partial model.UserSelfUpdate) error {
+	partial.Username = normalizeUsername(partial.Username)
+	partial.Email = normalizeEmail(partial.Email)
+
+	err := Validate(u.validate, partial)
+	if err != nil {
+		return err
+	}
+
+	return u.Update(userID, model.AnyVersion, model.UserUpdate{ //nolint:exhaustruct
+		Name:     partial.Name,
+		Username: partial.Username,
+		Email:    partial.Email,
+	})
+}
+
+// ChangePassword changes the user password after checking the current one.
+func (u *User) ChangePassword(userID model.ID, partial model.PasswordUpdate) error {
+	err := Validate(u.validate, partial)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	equal, err := u.EqualPassword(partial.CurrentPassword, user.Password)
+	if err != nil {
+		return fmt.Errorf("erro checking password: %w", err)
+	}
+
+	if !equal {
+		return errs.ErrPasswordDoesNotMatch
+	}
+
+	return u.Update(
+		user.ID,
+		user.Version,
+		model.UserUpdate{Password: partial.NewPassword}, //nolint:exhaustruct
+	)
+}
+
 func (u *User) Delete(userID model.ID, deleteByID model.ID) error {
 	user, err := u.GetByID(userID)
 	if err != nil {
@@ -244,42 +464,168 @@ func (u *User) Delete(userID model.ID, deleteByID model.ID) error {
 	return nil
 }
 
-func (u *User) createHash(password string) (string, error) {
-	if u.argonEnable {
-		hash, err := argon2id.CreateHash(password, &u.argon2id)
-		if err != nil {
-			return "", fmt.Errorf("error creating password hash: %w", err)
+// Restore undoes the soft delete of a user.
+func (u *User) Restore(restoredBy model.ID, userID model.ID) error {
+	_, err := u.GetByID(userID)
+	if err == nil {
+		return errs.ErrUserNotDeleted
+	}
+
+	if !errors.Is(err, errs.ErrUserNotFound) {
+		return err
+	}
+
+	err = u.database.Restore(userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return errs.ErrUserNotFound
 		}
 
-		return hash, nil
+		if existErr := alreadyExist(err); existErr != nil {
+			return existErr
+		}
+
+		return fmt.Errorf("error restoring user in database: %w", err)
 	}
 
-	hash := sha256.Sum256([]byte(password))
+	log.Printf("[INFO] - User '%s' restored user '%s'", restoredBy, userID)
 
-	return fmt.Sprintf("%x", hash), nil
+	return nil
 }
 
-func (u *User) EqualPassword(password string, hash string) (bool, error) {
-	if u.argonEnable {
-		match, err := argon2id.ComparePasswordAndHash(password, hash)
+// normalizeUsername returns the NFKC form of the username, so usernames that
+// only differ by compatibility characters, like full width letters, are the
+// same. The case is kept, the database compares usernames ignoring it.
+func normalizeUsername(username string) string {
+	return norm.NFKC.String(username)
+}
+
+// normalizeEmail lower cases the email, emails are stored and compared in
+// lower case.
+func normalizeEmail(email string) string {
+	return strings.ToLower(email)
+}
+
+// alreadyExist returns the sentinel error when the database refused the user
+// because the username or the email is used by another user, or nil. The
+// database checks it, so concurrent requests can not both take them.
+func alreadyExist(err error) error {
+	switch {
+	case errors.Is(err, errs.ErrUsernameAlreadyExist):
+		return errs.ErrUsernameAlreadyExist
+	case errors.Is(err, errs.ErrEmailAlreadyExist):
+		return errs.ErrEmailAlreadyExist
+	default:
+		return nil
+	}
+}
+
+// PasswordExpired reports whether the user has to change the password before
+// using the system, either because it was flagged or because it is too old.
+func (u *User) PasswordExpired(user model.User) bool {
+	if user.MustChangePassword {
+		return true
+	}
+
+	return u.maxAge > 0 && time.Since(user.PasswordChangedAt) > u.maxAge
+}
+
+// checkPasswordHistory verifies that the password is not the current one nor
+// one of the last previous passwords, up to history passwords in total.
+func (u *User) checkPasswordHistory(user model.User, password string) error {
+	if u.history <= 0 {
+		return nil
+	}
+
+	previous, err := u.database.GetPasswords(user.ID, u.history-1)
+	if err != nil {
+		return fmt.Errorf("error on getting user passwords from database: %w", err)
+	}
+
+	for _, hash := range append([]string{user.Password}, previous...) {
+		equal, err := u.EqualPassword(password, hash)
 		if err != nil {
-			return false, fmt.Errorf("error comparaing hash: %w", err)
+			return err
+		}
+
+		if equal {
+			return NewPasswordReusedError(u.history)
 		}
+	}
+
+	return nil
+}
+
+func (u *User) createHash(password string) (string, error) {
+	var (
+		hash    string
+		errHash error
+	)
+
+	err := u.pool.Run("create", func() { hash, errHash = u.hash.Create(password) })
+	if err != nil {
+		return "", err
+	}
+
+	return hash, errHash
+}
+
+func (u *User) EqualPassword(password string, hash string) (bool, error) {
+	var (
+		match   bool
+		errHash error
+	)
+
+	err := u.pool.Run("compare", func() { match, errHash = u.hash.Compare(password, hash) })
+	if err != nil {
+		return false, err
+	}
+
+	if errHash != nil {
+		return false, fmt.Errorf("error comparaing hash: %w", errHash)
+	}
+
+	return match, nil
+}
+
+// NeedsRehash reports whether the hash is weak or outdated compared to the
+// configured password hash.
+func (u *User) NeedsRehash(hash string) bool {
+	return u.hash.NeedsRehash(hash)
+}
 
-		return match, nil
+// Rehash stores a new hash of an already verified password, keeping the
+// password history and age untouched.
+func (u *User) Rehash(userID model.ID, password string) error {
+	hash, err := u.createHash(password)
+	if err != nil {
+		return err
 	}
 
-	hashp := fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+	err = u.database.UpdatePassword(userID, hash)
+	if err != nil {
+		return fmt.Errorf("error updating user password in the database: %w", err)
+	}
 
-	return hash == hashp, nil
+	return nil
 }
 
-func NewUser(database data.User, role *Role, validate *validator.Validate, argonEnable bool) *User {
+func NewUser(
+	database data.User,
+	role *Role,
+	validate *validator.Validate,
+	hash PasswordHash,
+	pool *PasswordPool,
+	passwordHistory int,
+	passwordMaxAge time.Duration,
+) *User {
 	return &User{
-		database:    database,
-		role:        role,
-		validate:    validate,
-		argon2id:    *argon2id.DefaultParams,
-		argonEnable: argonEnable,
+		database: database,
+		role:     role,
+		validate: validate,
+		hash:     hash,
+		pool:     pool,
+		history:  passwordHistory,
+		maxAge:   passwordMaxAge,
 	}
 }
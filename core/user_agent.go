@@ -0,0 +1,71 @@
+package core
+
+import "strings"
+
+const maxUserAgentLength = 512
+
+type userAgentRule struct {
+	token string
+	name  string
+}
+
+// the order matters, browsers based on Chromium also send "Chrome" and
+// "Safari" and Android also sends "Linux"
+//
+//nolint:gochecknoglobals
+var (
+	browserRules = []userAgentRule{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	systemRules = []userAgentRule{
+		{"Windows", "Windows"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+func matchUserAgent(userAgent string, rules []userAgentRule) string {
+	for _, rule := range rules {
+		if strings.Contains(userAgent, rule.token) {
+			return rule.name
+		}
+	}
+
+	return ""
+}
+
+// ParseUserAgent returns a short summary of the browser and operating system
+// of a user agent, like "Chrome on Windows".
+func ParseUserAgent(userAgent string) string {
+	browser := matchUserAgent(userAgent, browserRules)
+	system := matchUserAgent(userAgent, systemRules)
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	case userAgent != "":
+		return "Unknown"
+	default:
+		return ""
+	}
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+
+	return strings.ToValidUTF8(value[:length], "")
+}
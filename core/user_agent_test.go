@@ -0,0 +1,49 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thiago-felipe-99/autenticacao/core"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	t.Parallel()
+
+	userAgents := []struct {
+		userAgent string
+		device    string
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) " +
+				"Chrome/115.0.0.0 Safari/537.36",
+			"Chrome on Windows",
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) " +
+				"Chrome/115.0.0.0 Safari/537.36 Edg/115.0.1901.188",
+			"Edge on Windows",
+		},
+		{
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/116.0",
+			"Firefox on Linux",
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 " +
+				"(KHTML, like Gecko) Version/16.5 Mobile/15E148 Safari/604.1",
+			"Safari on iOS",
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) " +
+				"Chrome/115.0.0.0 Mobile Safari/537.36",
+			"Chrome on Android",
+		},
+		{"curl/8.1.2", "curl"},
+		{"something else", "Unknown"},
+		{"", ""},
+	}
+
+	for _, userAgent := range userAgents {
+		require.Equal(t, userAgent.device, core.ParseUserAgent(userAgent.userAgent), userAgent.userAgent)
+	}
+}
@@ -3,6 +3,8 @@ package core
 import (
 	"errors"
 	"fmt"
+	"log"
+	"slices"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -11,17 +13,59 @@ import (
 	"github.com/thiago-felipe-99/autenticacao/model"
 )
 
+// SessionLimit caps how many active sessions a user can have. Roles overrides
+// Default for users with those roles, using the highest limit among the user
+// roles, and Evict chooses between evicting the oldest sessions or rejecting
+// new ones. A limit of 0 means no limit.
+type SessionLimit struct {
+	Default int
+	Roles   map[string]int
+	Evict   bool
+}
+
+// For returns the session limit of a user with the roles.
+func (s SessionLimit) For(roles []string) int {
+	limit, found := 0, false
+
+	for _, role := range roles {
+		roleLimit, ok := s.Roles[role]
+		if !ok {
+			continue
+		}
+
+		if roleLimit == 0 {
+			return 0
+		}
+
+		limit, found = max(limit, roleLimit), true
+	}
+
+	if !found {
+		return s.Default
+	}
+
+	return limit
+}
+
 type UserSession struct {
-	database  data.UserSession
-	user      *User
-	validator *validator.Validate
-	expires   time.Duration
+	database            data.UserSession
+	user                *User
+	validator           *validator.Validate
+	idleTimeout         time.Duration
+	maxLifetime         time.Duration
+	rememberIdleTimeout time.Duration
+	rememberMaxLifetime time.Duration
+	threshold           time.Duration
+	grace               time.Duration
+	limit               SessionLimit
 }
 
-func (u *UserSession) GetAllActive(paginate int, qt int) ([]model.UserSession, error) {
-	userSessions, err := u.database.GetAllActive(paginate, qt)
+func (u *UserSession) GetAllActive(
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	userSessions, err := u.database.GetAllActive(pagination)
 	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf(
+		return model.EmptyPage[model.UserSession](), fmt.Errorf(
 			"error getting users sessions from database: %w",
 			err,
 		)
@@ -30,14 +74,15 @@ func (u *UserSession) GetAllActive(paginate int, qt int) ([]model.UserSession, e
 	return userSessions, nil
 }
 
+// GetByUserIDActive returns the active sessions used by the user, the
+// impersonated sessions are sessions of the impersonator.
 func (u *UserSession) GetByUserIDActive(
 	userID model.ID,
-	paginate int,
-	qt int,
-) ([]model.UserSession, error) {
-	userSessions, err := u.database.GetByUserIDActive(userID, paginate, qt)
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	userSessions, err := u.database.GetByUserIDActive(userID, pagination)
 	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf(
+		return model.EmptyPage[model.UserSession](), fmt.Errorf(
 			"error getting users sessions from database: %w",
 			err,
 		)
@@ -46,10 +91,12 @@ func (u *UserSession) GetByUserIDActive(
 	return userSessions, nil
 }
 
-func (u *UserSession) GetAllInactive(paginate int, qt int) ([]model.UserSession, error) {
-	userSessions, err := u.database.GetAllInactive(paginate, qt)
+func (u *UserSession) GetAllInactive(
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	userSessions, err := u.database.GetAllInactive(pagination)
 	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf(
+		return model.EmptyPage[model.UserSession](), fmt.Errorf(
 			"error getting users sessions from database: %w",
 			err,
 		)
@@ -58,14 +105,15 @@ func (u *UserSession) GetAllInactive(paginate int, qt int) ([]model.UserSession,
 	return userSessions, nil
 }
 
+// GetByUserIDInactive returns the inactive sessions used by the user, the
+// impersonated sessions are sessions of the impersonator.
 func (u *UserSession) GetByUserIDInactive(
 	userID model.ID,
-	paginate int,
-	qt int,
-) ([]model.UserSession, error) {
-	userSessions, err := u.database.GetByUserIDInactive(userID, paginate, qt)
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	userSessions, err := u.database.GetByUserIDInactive(userID, pagination)
 	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf(
+		return model.EmptyPage[model.UserSession](), fmt.Errorf(
 			"error getting users sessions from database: %w",
 			err,
 		)
@@ -117,26 +165,88 @@ func (u *UserSession) Create(partial model.UserSessionPartial) (model.UserSessio
 		return model.EmptyUserSession, errs.ErrPasswordDoesNotMatch
 	}
 
+	if u.user.NeedsRehash(user.Password) {
+		err = u.user.Rehash(user.ID, partial.Password)
+		if err != nil {
+			log.Printf("[ERROR] - error rehashing user '%s' password: %s", user.ID, err)
+		}
+	}
+
+	userAgent := truncate(partial.UserAgent, maxUserAgentLength)
+	now := time.Now()
+
 	userSession := model.UserSession{
-		ID:        model.NewID(),
-		UserID:    user.ID,
-		CreateaAt: time.Now(),
-		Expires:   time.Now().Add(u.expires),
-		DeletedAt: time.Time{},
+		ID:                   model.NewID(),
+		UserID:               user.ID,
+		CreateaAt:            now,
+		OriginalCreatedAt:    now,
+		Expires:              u.expiration(partial.RememberMe, now, now),
+		DeletedAt:            time.Time{},
+		Restricted:           u.user.PasswordExpired(user),
+		RememberMe:           partial.RememberMe,
+		AuthenticatedAt:      now,
+		AuthenticationMethod: model.AuthenticationPassword,
+		ImpersonatorID:       model.EmptyID,
+		IP:                   partial.IP,
+		UserAgent:            userAgent,
+		Device:               ParseUserAgent(userAgent),
+		DeviceName:           partial.DeviceName,
 	}
 
-	err = u.database.Create(userSession)
+	evicted, err := u.database.CreateWithLimit(userSession, u.limit.For(user.Roles), u.limit.Evict)
 	if err != nil {
+		if errors.Is(err, errs.ErrUserSessionLimit) {
+			return model.EmptyUserSession, errs.ErrUserSessionLimit
+		}
+
 		return model.EmptyUserSession, fmt.Errorf(
 			"error creating user session on database: %w",
 			err,
 		)
 	}
 
+	for _, evictedSession := range evicted {
+		if evictedSession.ImpersonatorID != model.EmptyID {
+			data.LogImpersonationEnd(evictedSession, "session evicted by the session limit")
+		}
+	}
+
 	return userSession, nil
 }
 
 func (u *UserSession) Delete(id model.ID) (model.UserSession, error) {
+	userSession, err := u.remove(id)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	if userSession.ImpersonatorID != model.EmptyID {
+		data.LogImpersonationEnd(userSession, "session deleted")
+	}
+
+	return userSession, nil
+}
+
+// DeleteByUserID deletes all the sessions of the user, ending the
+// impersonations done by them.
+func (u *UserSession) DeleteByUserID(userID model.ID) error {
+	deleted, err := u.database.DeleteByUserID(userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("error deleting user sessions from database: %w", err)
+	}
+
+	for _, userSession := range deleted {
+		if userSession.ImpersonatorID != model.EmptyID {
+			data.LogImpersonationEnd(userSession, "impersonator sessions deleted")
+		}
+	}
+
+	return nil
+}
+
+// remove deletes the session without ending an impersonation, for sessions
+// that are replaced by a new one.
+func (u *UserSession) remove(id model.ID) (model.UserSession, error) {
 	userSession, err := u.database.Delete(id, time.Now())
 	if err != nil {
 		if errors.Is(err, errs.ErrUserSessionNotFound) {
@@ -152,18 +262,270 @@ func (u *UserSession) Delete(id model.ID) (model.UserSession, error) {
 	return userSession, nil
 }
 
+// Refresh extends the session by replacing it with a new one, but only when it
+// expires within the refresh threshold. Otherwise the session is just validated
+// and returned as it is, avoiding writes on every request. A session replaced
+// less than a grace period ago resolves to its successor, so parallel requests
+// sent with the old session do not fail. The session is restricted again
+// whenever the password of the user expires.
 func (u *UserSession) Refresh(id model.ID) (model.UserSession, error) {
-	userSession, err := u.Delete(id)
+	userSession, err := u.refresh(id)
 	if err != nil {
 		return model.EmptyUserSession, err
 	}
 
+	return u.restrict(userSession)
+}
+
+// restrict updates the restriction of the session with the current password of
+// the user, rejecting sessions of deleted users. Impersonated sessions are never
+// restricted, the impersonator does not change the password of the user.
+func (u *UserSession) restrict(userSession model.UserSession) (model.UserSession, error) {
+	user, err := u.user.GetByID(userSession.UserID)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return model.EmptyUserSession, errs.ErrUserSessionNotFound
+		}
+
+		return model.EmptyUserSession, err
+	}
+
+	if userSession.ImpersonatorID != model.EmptyID {
+		return userSession, nil
+	}
+
+	userSession.Restricted = u.user.PasswordExpired(user)
+
+	return userSession, nil
+}
+
+func (u *UserSession) refresh(id model.ID) (model.UserSession, error) {
+	userSession, err := u.GetByID(id)
+	if errors.Is(err, errs.ErrUserSessionNotFound) && u.grace > 0 {
+		return u.successor(id)
+	}
+
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	now := time.Now()
+
+	if !now.Before(userSession.Expires) {
+		return model.EmptyUserSession, errs.ErrUserSessionNotFound
+	}
+
+	if userSession.Expires.Sub(now) > u.threshold ||
+		!u.expiration(userSession.RememberMe, userSession.OriginalCreatedAt, now).
+			After(userSession.Expires) {
+		return userSession, nil
+	}
+
+	return u.rotate(userSession, u.next(userSession, now), now)
+}
+
+// next returns the session that replaces userSession at now, keeping the
+// original creation and authentication.
+func (u *UserSession) next(userSession model.UserSession, now time.Time) model.UserSession {
+	return model.UserSession{
+		ID:                   model.NewID(),
+		UserID:               userSession.UserID,
+		CreateaAt:            now,
+		OriginalCreatedAt:    userSession.OriginalCreatedAt,
+		Expires:              u.expiration(userSession.RememberMe, userSession.OriginalCreatedAt, now),
+		DeletedAt:            time.Time{},
+		Restricted:           userSession.Restricted,
+		RememberMe:           userSession.RememberMe,
+		AuthenticatedAt:      userSession.AuthenticatedAt,
+		AuthenticationMethod: userSession.AuthenticationMethod,
+		ImpersonatorID:       userSession.ImpersonatorID,
+		IP:                   userSession.IP,
+		UserAgent:            userSession.UserAgent,
+		Device:               userSession.Device,
+		DeviceName:           userSession.DeviceName,
+	}
+}
+
+func (u *UserSession) rotate(
+	userSession model.UserSession,
+	next model.UserSession,
+	now time.Time,
+) (model.UserSession, error) {
+	next, err := u.database.Rotate(userSession.ID, next, now, u.grace)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserSessionNotFound) {
+			return model.EmptyUserSession, errs.ErrUserSessionNotFound
+		}
+
+		return model.EmptyUserSession, fmt.Errorf(
+			"error rotating user session on database: %w",
+			err,
+		)
+	}
+
+	return next, nil
+}
+
+func (u *UserSession) successor(id model.ID) (model.UserSession, error) {
+	userSession, err := u.database.GetSuccessor(id)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserSessionNotFound) {
+			return model.EmptyUserSession, errs.ErrUserSessionNotFound
+		}
+
+		return model.EmptyUserSession, fmt.Errorf(
+			"error getting user session successor from database: %w",
+			err,
+		)
+	}
+
+	return userSession, nil
+}
+
+// ChangePassword changes the password of the session user after checking the
+// current one, replacing the session by a new one without restrictions.
+func (u *UserSession) ChangePassword(
+	id model.ID,
+	partial model.PasswordUpdate,
+) (model.UserSession, error) {
+	userSession, err := u.GetByID(id)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	err = u.user.ChangePassword(userSession.UserID, partial)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	_, err = u.remove(id)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	now := time.Now()
+
 	userSession = model.UserSession{
-		ID:        model.NewID(),
-		UserID:    userSession.UserID,
-		CreateaAt: time.Now(),
-		Expires:   time.Now().Add(u.expires),
-		DeletedAt: time.Time{},
+		ID:                   model.NewID(),
+		UserID:               userSession.UserID,
+		CreateaAt:            now,
+		OriginalCreatedAt:    now,
+		Expires:              u.expiration(userSession.RememberMe, now, now),
+		DeletedAt:            time.Time{},
+		Restricted:           false,
+		RememberMe:           userSession.RememberMe,
+		AuthenticatedAt:      now,
+		AuthenticationMethod: model.AuthenticationPassword,
+		ImpersonatorID:       userSession.ImpersonatorID,
+		IP:                   userSession.IP,
+		UserAgent:            userSession.UserAgent,
+		Device:               userSession.Device,
+		DeviceName:           userSession.DeviceName,
+	}
+
+	err = u.database.Create(userSession)
+	if err != nil {
+		return model.EmptyUserSession, fmt.Errorf(
+			"error creating user session on database: %w",
+			err,
+		)
+	}
+
+	return userSession, nil
+}
+
+// Reauthenticate checks the password of the session user again, replacing the
+// session by one authenticated now, as required by sensitive operations. It is
+// the only way a remember me session can do them.
+func (u *UserSession) Reauthenticate(
+	id model.ID,
+	partial model.Reauthentication,
+) (model.UserSession, error) {
+	err := Validate(u.validator, partial)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	userSession, err := u.GetByID(id)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	user, err := u.user.GetByID(userSession.UserID)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	equal, err := u.user.EqualPassword(partial.Password, user.Password)
+	if err != nil {
+		return model.EmptyUserSession, fmt.Errorf("erro checking password: %w", err)
+	}
+
+	if !equal {
+		return model.EmptyUserSession, errs.ErrPasswordDoesNotMatch
+	}
+
+	now := time.Now()
+
+	next := u.next(userSession, now)
+	next.AuthenticatedAt = now
+	next.AuthenticationMethod = model.AuthenticationReauthentication
+
+	return u.rotate(userSession, next, now)
+}
+
+// Impersonate creates a session of the user userID for the user of the session
+// id, who must have the impersonator role. The new session keeps the
+// impersonator ID, so it can not be used to impersonate again, and it is never
+// authenticated, so it can not do operations requiring a recent authentication.
+// It belongs to the impersonator, so it is not limited nor listed with the
+// sessions of the user.
+func (u *UserSession) Impersonate(
+	id model.ID,
+	userID model.ID,
+	impersonatorRole string,
+) (model.UserSession, error) {
+	impersonatorSession, err := u.GetByID(id)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	if impersonatorSession.ImpersonatorID != model.EmptyID {
+		return model.EmptyUserSession, errs.ErrNestedImpersonation
+	}
+
+	impersonator, err := u.user.GetByID(impersonatorSession.UserID)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	if impersonatorRole == "" || !slices.Contains(impersonator.Roles, impersonatorRole) {
+		return model.EmptyUserSession, errs.ErrImpersonationDenied
+	}
+
+	user, err := u.user.GetByID(userID)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	now := time.Now()
+
+	userSession := model.UserSession{
+		ID:                   model.NewID(),
+		UserID:               user.ID,
+		CreateaAt:            now,
+		OriginalCreatedAt:    now,
+		Expires:              u.expiration(false, now, now),
+		DeletedAt:            time.Time{},
+		Restricted:           false,
+		RememberMe:           false,
+		AuthenticatedAt:      time.Time{},
+		AuthenticationMethod: model.AuthenticationImpersonation,
+		ImpersonatorID:       impersonator.ID,
+		IP:                   impersonatorSession.IP,
+		UserAgent:            impersonatorSession.UserAgent,
+		Device:               impersonatorSession.Device,
+		DeviceName:           impersonatorSession.DeviceName,
 	}
 
 	err = u.database.Create(userSession)
@@ -174,19 +536,83 @@ func (u *UserSession) Refresh(id model.ID) (model.UserSession, error) {
 		)
 	}
 
+	log.Printf(
+		"[INFO] - User '%s' started impersonating user '%s' with session '%s'",
+		impersonator.ID,
+		user.ID,
+		userSession.ID,
+	)
+
 	return userSession, nil
 }
 
+// StopImpersonation deletes an impersonated session, the impersonator keeps
+// using their own session.
+func (u *UserSession) StopImpersonation(id model.ID) (model.UserSession, error) {
+	userSession, err := u.GetByID(id)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	if userSession.ImpersonatorID == model.EmptyID {
+		return model.EmptyUserSession, errs.ErrNotImpersonating
+	}
+
+	userSession, err = u.remove(id)
+	if err != nil {
+		return model.EmptyUserSession, err
+	}
+
+	data.LogImpersonationEnd(userSession, "stopped by the impersonator")
+
+	return userSession, nil
+}
+
+// expiration returns when a session refreshed at now expires, that is after
+// the idle timeout but never after the maximum lifetime of the first session.
+// Remember me sessions use their own idle timeout and maximum lifetime.
+func (u *UserSession) expiration(
+	rememberMe bool,
+	originalCreatedAt time.Time,
+	now time.Time,
+) time.Time {
+	idleTimeout, maxLifetime := u.idleTimeout, u.maxLifetime
+	if rememberMe {
+		idleTimeout, maxLifetime = u.rememberIdleTimeout, u.rememberMaxLifetime
+	}
+
+	idle := now.Add(idleTimeout)
+	limit := originalCreatedAt.Add(maxLifetime)
+
+	if idle.After(limit) {
+		return limit
+	}
+
+	return idle
+}
+
 func NewUserSession(
 	db data.UserSession,
 	user *User,
 	validate *validator.Validate,
-	expires time.Duration,
+	idleTimeout time.Duration,
+	maxLifetime time.Duration,
+	rememberIdleTimeout time.Duration,
+	rememberMaxLifetime time.Duration,
+	refreshThreshold time.Duration,
+	refreshGrace time.Duration,
+	limit SessionLimit,
 ) *UserSession {
 	return &UserSession{
-		database:  db,
-		user:      user,
-		validator: validate,
-		expires:   expires,
+		database:            db,
+		user:                user,
+		validator:           validate,
+		idleTimeout:         idleTimeout,
+		maxLifetime:         maxLifetime,
+		rememberIdleTimeout: rememberIdleTimeout,
+		rememberMaxLifetime: rememberMaxLifetime,
+		threshold:           refreshThreshold,
+		grace:               refreshGrace,
+		limit:               limit,
 	}
 }
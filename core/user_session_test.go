@@ -1,11 +1,14 @@
 package core_test
 
 import (
+	"runtime"
 	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,6 +32,235 @@ func logErros(t *testing.T, errs <-chan error) {
 	}()
 }
 
+// memorySessions keeps sessions in memory, counting the writes the Redis
+// implementation would do and the rows it would send to Postgres.
+type memorySessions struct {
+	mutex      sync.Mutex
+	sessions   map[model.ID]model.UserSession
+	successors map[model.ID]memorySuccessor
+	writes     int
+	rows       int
+}
+
+type memorySuccessor struct {
+	id      model.ID
+	expires time.Time
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{ //nolint:exhaustruct
+		sessions:   map[model.ID]model.UserSession{},
+		successors: map[model.ID]memorySuccessor{},
+	}
+}
+
+func (m *memorySessions) GetByID(id model.ID) (model.UserSession, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	userSession, ok := m.sessions[id]
+	if !ok || time.Now().After(userSession.Expires) {
+		return model.EmptyUserSession, errs.ErrUserSessionNotFound
+	}
+
+	return userSession, nil
+}
+
+func (m *memorySessions) GetAllActive(model.Pagination) (model.Page[model.UserSession], error) {
+	return model.EmptyPage[model.UserSession](), nil
+}
+
+func (m *memorySessions) GetByUserIDActive(
+	model.ID,
+	model.Pagination,
+) (model.Page[model.UserSession], error) {
+	return model.EmptyPage[model.UserSession](), nil
+}
+
+func (m *memorySessions) GetAllInactive(model.Pagination) (model.Page[model.UserSession], error) {
+	return model.EmptyPage[model.UserSession](), nil
+}
+
+func (m *memorySessions) GetByUserIDInactive(
+	model.ID,
+	model.Pagination,
+) (model.Page[model.UserSession], error) {
+	return model.EmptyPage[model.UserSession](), nil
+}
+
+func (m *memorySessions) Create(userSession model.UserSession) error {
+	_, err := m.CreateWithLimit(userSession, 0, false)
+
+	return err
+}
+
+func (m *memorySessions) CreateWithLimit(
+	userSession model.UserSession,
+	limit int,
+	evict bool,
+) ([]model.UserSession, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	evicted := []model.UserSession{}
+
+	if limit > 0 {
+		actives := []model.UserSession{}
+
+		for _, active := range m.sessions {
+			if active.UserID == userSession.UserID && time.Now().Before(active.Expires) {
+				actives = append(actives, active)
+			}
+		}
+
+		if len(actives) >= limit && !evict {
+			return model.EmptyUserSessions, errs.ErrUserSessionLimit
+		}
+
+		slices.SortFunc(actives, func(a, b model.UserSession) int {
+			return a.OriginalCreatedAt.Compare(b.OriginalCreatedAt)
+		})
+
+		for len(actives) >= limit {
+			delete(m.sessions, actives[0].ID)
+			evicted = append(evicted, actives[0])
+			actives = actives[1:]
+			m.writes++
+			m.rows++
+		}
+	}
+
+	m.sessions[userSession.ID] = userSession
+	m.writes++
+	m.rows++
+
+	return evicted, nil
+}
+
+func (m *memorySessions) Delete(id model.ID, deletedAt time.Time) (model.UserSession, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	userSession, ok := m.sessions[id]
+	if !ok {
+		return model.EmptyUserSession, errs.ErrUserSessionNotFound
+	}
+
+	delete(m.sessions, id)
+
+	userSession.DeletedAt = deletedAt
+	m.writes++
+	m.rows++
+
+	return userSession, nil
+}
+
+func (m *memorySessions) DeleteByUserID(
+	userID model.ID,
+	deletedAt time.Time,
+) ([]model.UserSession, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	deleted := []model.UserSession{}
+
+	for id, userSession := range m.sessions {
+		if userSession.UserID == userID || userSession.ImpersonatorID == userID {
+			delete(m.sessions, id)
+
+			userSession.DeletedAt = deletedAt
+			deleted = append(deleted, userSession)
+			m.writes++
+			m.rows++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (m *memorySessions) Rotate(
+	id model.ID,
+	next model.UserSession,
+	deletedAt time.Time,
+	grace time.Duration,
+) (model.UserSession, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	successor, ok := m.successors[id]
+	if ok && time.Now().Before(successor.expires) {
+		userSession, ok := m.sessions[successor.id]
+		if !ok {
+			return model.EmptyUserSession, errs.ErrUserSessionNotFound
+		}
+
+		return userSession, nil
+	}
+
+	_, ok = m.sessions[id]
+	if !ok {
+		return model.EmptyUserSession, errs.ErrUserSessionNotFound
+	}
+
+	delete(m.sessions, id)
+	m.sessions[next.ID] = next
+
+	if grace > 0 {
+		m.successors[id] = memorySuccessor{next.ID, deletedAt.Add(grace)}
+	}
+
+	m.writes += 2
+	m.rows += 2
+
+	return next, nil
+}
+
+func (m *memorySessions) GetSuccessor(id model.ID) (model.UserSession, error) {
+	m.mutex.Lock()
+	successor, ok := m.successors[id]
+	m.mutex.Unlock()
+
+	if !ok || time.Now().After(successor.expires) {
+		return model.EmptyUserSession, errs.ErrUserSessionNotFound
+	}
+
+	return m.GetByID(successor.id)
+}
+
+func (m *memorySessions) reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.writes, m.rows = 0, 0
+}
+
+func createMemorySession(
+	t testing.TB,
+	sessions *memorySessions,
+	idleTimeout time.Duration,
+) model.UserSession {
+	t.Helper()
+
+	now := time.Now()
+
+	userSession := model.UserSession{ //nolint:exhaustruct
+		ID:                   model.NewID(),
+		UserID:               model.NewID(),
+		CreateaAt:            now,
+		OriginalCreatedAt:    now,
+		Expires:              now.Add(idleTimeout),
+		AuthenticatedAt:      now,
+		AuthenticationMethod: model.AuthenticationPassword,
+	}
+
+	err := sessions.Create(userSession)
+	require.NoError(t, err)
+
+	sessions.reset()
+
+	return userSession
+}
+
 func TestUserSessionCreate(t *testing.T) { //nolint:funlen
 	t.Parallel()
 
@@ -42,13 +274,19 @@ func TestUserSessionCreate(t *testing.T) { //nolint:funlen
 	overflowBuffer := buffer * 10
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
 	userSession := core.NewUserSession(
 		userSessionRedis,
 		user,
 		model.Validate(),
 		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
 	)
 
 	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
@@ -103,226 +341,1496 @@ func TestUserSessionCreate(t *testing.T) { //nolint:funlen
 			for _, input := range inputs {
 				input := input
 
-				t.Run(input.name, func(t *testing.T) {
-					t.Parallel()
+				t.Run(input.name, func(t *testing.T) {
+					t.Parallel()
+
+					userSessionTemp, err := userSession.Create(input.input)
+					require.NoError(t, err)
+
+					require.Equal(t, userID, userSessionTemp.UserID)
+					require.LessOrEqual(t, time.Since(userSessionTemp.CreateaAt), time.Second)
+					require.Equal(t, time.Time{}, userSessionTemp.DeletedAt)
+
+					userSessionDB, err := userSession.GetByID(userSessionTemp.ID)
+					require.NoError(t, err)
+
+					require.Equal(t, userSessionDB.ID, userSessionTemp.ID)
+					require.Equal(t, userSessionDB.UserID, userSessionTemp.UserID)
+					require.True(t, userSessionDB.CreateaAt.Equal(userSessionTemp.CreateaAt))
+					require.True(t, userSessionDB.DeletedAt.Equal(userSessionTemp.DeletedAt))
+				})
+			}
+		})
+
+		t.Run("InvalidInputs", func(t *testing.T) {
+			t.Parallel()
+
+			//nolint:exhaustruct
+			inputs := []struct {
+				name  string
+				input model.UserSessionPartial
+			}{
+				{
+					"UsernameWithEmail",
+					model.UserSessionPartial{
+						Email:    userInput.Email,
+						Username: userInput.Email,
+						Password: userInput.Password,
+					},
+				},
+				{
+					"UsernameWhitoutPassword",
+					model.UserSessionPartial{Username: userInput.Username},
+				},
+				{
+					"EmailWhitoutPassword",
+					model.UserSessionPartial{Email: userInput.Email},
+				},
+			}
+
+			for _, input := range inputs {
+				input := input
+
+				t.Run(input.name, func(t *testing.T) {
+					t.Parallel()
+
+					userSessionCreated, err := userSession.Create(input.input)
+					require.ErrorAs(t, err, &core.InvalidError{})
+					require.Equal(t, userSessionCreated, model.EmptyUserSession)
+				})
+			}
+		})
+
+		t.Run("WrongPassword", func(t *testing.T) {
+			t.Parallel()
+			//nolint:exhaustruct
+			inputs := []struct {
+				name  string
+				input model.UserSessionPartial
+			}{
+				{
+					"Username",
+					model.UserSessionPartial{
+						Username: userInput.Username,
+						Password: gofakeit.Password(true, true, true, true, true, 20),
+					},
+				},
+				{
+					"Email",
+					model.UserSessionPartial{
+						Email:    userInput.Email,
+						Password: gofakeit.Password(true, true, true, true, true, 20),
+					},
+				},
+			}
+
+			for _, input := range inputs {
+				input := input
+
+				t.Run(input.name, func(t *testing.T) {
+					t.Parallel()
+
+					userSessionCreated, err := userSession.Create(input.input)
+					require.ErrorIs(t, err, errs.ErrPasswordDoesNotMatch)
+					require.Equal(t, userSessionCreated, model.EmptyUserSession)
+				})
+			}
+		})
+
+		t.Run("UserNotFound", func(t *testing.T) {
+			t.Parallel()
+			//nolint:exhaustruct
+			inputs := []struct {
+				name  string
+				input model.UserSessionPartial
+			}{
+				{
+					"Username",
+					model.UserSessionPartial{
+						Username: gofakeit.Username(),
+						Password: gofakeit.Password(true, true, true, true, true, 20),
+					},
+				},
+				{
+					"Email",
+					model.UserSessionPartial{
+						Email:    gofakeit.Email(),
+						Password: gofakeit.Password(true, true, true, true, true, 20),
+					},
+				},
+			}
+
+			for _, input := range inputs {
+				input := input
+
+				t.Run(input.name, func(t *testing.T) {
+					t.Parallel()
+
+					userSessionCreated, err := userSession.Create(input.input)
+					require.ErrorIs(t, err, errs.ErrUserNotFound)
+					require.Equal(t, userSessionCreated, model.EmptyUserSession)
+				})
+			}
+		})
+	}
+
+	t.Run("UserSeesionNotExist", func(t *testing.T) {
+		t.Parallel()
+
+		userSession, err := userSession.GetByID(model.NewID())
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+		require.Equal(t, userSession, model.EmptyUserSession)
+	})
+}
+
+func TestUserSessionRefresh(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_refresh")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+	overflowBuffer := buffer * 10
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
+	)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	qtRoles := 5
+	rolesTemp := make([]string, qtRoles)
+
+	for i := range rolesTemp {
+		_, role := createTempRole(t, role, db)
+		rolesTemp[i] = role.Name
+	}
+
+	qtUsers := overflowBuffer
+	usersTemp := make([]partialUser, qtUsers)
+
+	for i := 0; i < qtUsers; i++ {
+		userid, createdBy, userTemp := createTempUser(t, user, db, rolesTemp)
+		usersTemp[i] = partialUser{
+			userID:    userid,
+			input:     userTemp,
+			createdBy: createdBy,
+			deletedBy: model.NewID(),
+		}
+	}
+
+	for _, userTemp := range usersTemp {
+		userID, userInput := userTemp.userID, userTemp.input
+
+		t.Run("ValiInputs", func(t *testing.T) {
+			t.Parallel()
+
+			UserSessionPartial := model.UserSessionPartial{ //nolint:exhaustruct
+				Username: userInput.Username,
+				Password: userInput.Password,
+			}
+
+			userSessionTemp1, err := userSession.Create(UserSessionPartial)
+			require.NoError(t, err)
+
+			userSessionTemp2, err := userSession.Refresh(userSessionTemp1.ID)
+			require.NoError(t, err)
+
+			require.NotEqual(t, userSessionTemp1.ID, userSessionTemp2.ID)
+			require.Equal(t, userID, userSessionTemp2.UserID)
+			require.LessOrEqual(t, time.Since(userSessionTemp2.CreateaAt), time.Second)
+			require.Equal(t, time.Time{}, userSessionTemp2.DeletedAt)
+		})
+
+		t.Run("UserSessionNotFound", func(t *testing.T) {
+			t.Parallel()
+
+			userSessionTemp, err := userSession.Refresh(model.NewID())
+			require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+			require.Equal(t, userSessionTemp, model.EmptyUserSession)
+		})
+	}
+}
+
+func TestUserSessionLifetime(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_lifetime")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Second*2,
+		time.Second*3,
+		time.Second*2,
+		time.Second*3,
+		time.Second*2,
+		0,
+		noSessionLimit(),
+	)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	_, _, userInput := createTempUser(t, user, db, []string{})
+
+	partial := model.UserSessionPartial{ //nolint:exhaustruct
+		Username: userInput.Username,
+		Password: userInput.Password,
+	}
+
+	t.Run("IdleTimeout", func(t *testing.T) {
+		t.Parallel()
+
+		created, err := userSession.Create(partial)
+		require.NoError(t, err)
+		require.True(t, created.OriginalCreatedAt.Equal(created.CreateaAt))
+		require.True(t, created.Expires.Equal(created.CreateaAt.Add(time.Second*2)))
+
+		time.Sleep(time.Second*2 + time.Millisecond*500)
+
+		_, err = userSession.GetByID(created.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		_, err = userSession.Refresh(created.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+
+	t.Run("MaxLifetime", func(t *testing.T) {
+		t.Parallel()
+
+		created, err := userSession.Create(partial)
+		require.NoError(t, err)
+
+		time.Sleep(time.Second * 2)
+
+		refreshed, err := userSession.Refresh(created.ID)
+		require.NoError(t, err)
+		require.True(t, refreshed.OriginalCreatedAt.Equal(created.OriginalCreatedAt))
+		require.True(t, refreshed.Expires.Equal(created.OriginalCreatedAt.Add(time.Second*3)))
+
+		time.Sleep(time.Millisecond * 500)
+
+		refreshed, err = userSession.Refresh(refreshed.ID)
+		require.NoError(t, err)
+		require.True(t, refreshed.Expires.Equal(created.OriginalCreatedAt.Add(time.Second*3)))
+
+		time.Sleep(time.Second)
+
+		_, err = userSession.Refresh(refreshed.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+}
+
+func TestUserSessionRefreshThreshold(t *testing.T) {
+	t.Parallel()
+
+	t.Run("FarFromExpiry", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Hour*24,
+			time.Minute,
+			0,
+			noSessionLimit(),
+		)
+		created := createMemorySession(t, sessions, time.Hour)
+
+		for i := 0; i < 10; i++ {
+			refreshed, err := userSession.Refresh(created.ID)
+			require.NoError(t, err)
+			require.Equal(t, created.ID, refreshed.ID)
+			require.True(t, created.Expires.Equal(refreshed.Expires))
+		}
+
+		require.Zero(t, sessions.writes)
+		require.Zero(t, sessions.rows)
+	})
+
+	t.Run("NearExpiry", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Hour*24,
+			time.Minute,
+			0,
+			noSessionLimit(),
+		)
+		created := createMemorySession(t, sessions, time.Second*30)
+
+		refreshed, err := userSession.Refresh(created.ID)
+		require.NoError(t, err)
+		require.NotEqual(t, created.ID, refreshed.ID)
+		require.True(t, created.OriginalCreatedAt.Equal(refreshed.OriginalCreatedAt))
+		require.True(t, created.AuthenticatedAt.Equal(refreshed.AuthenticatedAt))
+		require.Equal(t, created.AuthenticationMethod, refreshed.AuthenticationMethod)
+		require.WithinDuration(t, time.Now().Add(time.Hour), refreshed.Expires, time.Second)
+		require.Equal(t, 2, sessions.writes)
+		require.Equal(t, 2, sessions.rows)
+
+		_, err = userSession.GetByID(created.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+
+	t.Run("MaxLifetimeReached", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour,
+			time.Hour,
+			time.Hour,
+			time.Hour,
+			0,
+			noSessionLimit(),
+		)
+		created := createMemorySession(t, sessions, time.Hour)
+
+		refreshed, err := userSession.Refresh(created.ID)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, refreshed.ID)
+		require.Zero(t, sessions.writes)
+	})
+
+	t.Run("UserSessionNotFound", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour,
+			time.Hour,
+			time.Hour,
+			time.Hour,
+			0,
+			noSessionLimit(),
+		)
+
+		refreshed, err := userSession.Refresh(model.NewID())
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+		require.Equal(t, model.EmptyUserSession, refreshed)
+	})
+}
+
+func TestUserSessionRefreshGrace(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ConcurrentRequests", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Second*10,
+			noSessionLimit(),
+		)
+		created := createMemorySession(t, sessions, time.Hour)
+
+		qtRequests := 50
+		successors := make([]model.ID, qtRequests)
+		wait := sync.WaitGroup{}
+
+		for i := 0; i < qtRequests; i++ {
+			wait.Add(1)
+
+			go func(i int) {
+				defer wait.Done()
+
+				refreshed, err := userSession.Refresh(created.ID)
+				assert.NoError(t, err)
+
+				successors[i] = refreshed.ID
+			}(i)
+		}
+
+		wait.Wait()
+
+		for _, successor := range successors {
+			require.NotEqual(t, created.ID, successor)
+			require.Equal(t, successors[0], successor)
+		}
+
+		require.Equal(t, 2, sessions.writes)
+		require.Equal(t, 2, sessions.rows)
+	})
+
+	t.Run("GraceExpired", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Millisecond*100,
+			noSessionLimit(),
+		)
+		created := createMemorySession(t, sessions, time.Hour)
+
+		refreshed, err := userSession.Refresh(created.ID)
+		require.NoError(t, err)
+
+		_, err = userSession.Refresh(created.ID)
+		require.NoError(t, err)
+
+		time.Sleep(time.Millisecond * 200)
+
+		_, err = userSession.Refresh(created.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		_, err = userSession.GetByID(refreshed.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("WithoutGrace", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			0,
+			noSessionLimit(),
+		)
+		created := createMemorySession(t, sessions, time.Hour)
+
+		_, err := userSession.Refresh(created.ID)
+		require.NoError(t, err)
+
+		_, err = userSession.Refresh(created.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+
+	t.Run("SuccessorDeleted", func(t *testing.T) {
+		t.Parallel()
+
+		sessions := newMemorySessions()
+		userSession := core.NewUserSession(
+			sessions,
+			nil,
+			model.Validate(),
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Hour*24,
+			time.Hour,
+			time.Second*10,
+			noSessionLimit(),
+		)
+		created := createMemorySession(t, sessions, time.Hour)
+
+		refreshed, err := userSession.Refresh(created.ID)
+		require.NoError(t, err)
+
+		_, err = userSession.Delete(refreshed.ID)
+		require.NoError(t, err)
+
+		_, err = userSession.Refresh(created.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+}
+
+func BenchmarkUserSessionRefresh(b *testing.B) {
+	thresholds := []struct {
+		name      string
+		threshold time.Duration
+	}{
+		{"EveryRequest", time.Hour},
+		{"NearExpiry", time.Minute * 15},
+	}
+
+	for _, threshold := range thresholds {
+		b.Run(threshold.name, func(b *testing.B) {
+			sessions := newMemorySessions()
+			userSession := core.NewUserSession(
+				sessions,
+				nil,
+				model.Validate(),
+				time.Hour,
+				time.Hour*24,
+				time.Hour,
+				time.Hour*24,
+				threshold.threshold,
+				0,
+				noSessionLimit(),
+			)
+			session := createMemorySession(b, sessions, time.Hour)
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				refreshed, err := userSession.Refresh(session.ID)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				session = refreshed
+			}
+
+			b.ReportMetric(float64(sessions.writes)/float64(b.N), "redis_writes/op")
+			b.ReportMetric(float64(sessions.rows)/float64(b.N), "postgres_rows/op")
+		})
+	}
+}
+
+func TestSessionLimitFor(t *testing.T) {
+	t.Parallel()
+
+	limit := core.SessionLimit{
+		Default: 2,
+		Roles:   map[string]int{"support": 5, "kiosk": 1, "admin": 0},
+		Evict:   false,
+	}
+
+	require.Equal(t, 2, limit.For([]string{}))
+	require.Equal(t, 2, limit.For([]string{"other"}))
+	require.Equal(t, 1, limit.For([]string{"kiosk"}))
+	require.Equal(t, 5, limit.For([]string{"kiosk", "support", "other"}))
+	require.Equal(t, 0, limit.For([]string{"support", "admin"}))
+}
+
+func TestUserSessionLimit(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_limit")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	_, roleTemp := createTempRole(t, role, db)
+
+	newUserSession := func(limit core.SessionLimit) *core.UserSession {
+		return core.NewUserSession(
+			userSessionRedis,
+			user,
+			model.Validate(),
+			time.Minute,
+			time.Hour,
+			time.Minute,
+			time.Hour,
+			time.Minute,
+			0,
+			limit,
+		)
+	}
+
+	t.Run("Reject", func(t *testing.T) {
+		t.Parallel()
+
+		userSession := newUserSession(core.SessionLimit{
+			Default: 2,
+			Roles:   map[string]int{},
+			Evict:   false,
+		})
+
+		_, _, userInput := createTempUser(t, user, db, []string{})
+
+		partial := model.UserSessionPartial{ //nolint:exhaustruct
+			Username: userInput.Username,
+			Password: userInput.Password,
+		}
+
+		first, err := userSession.Create(partial)
+		require.NoError(t, err)
+
+		_, err = userSession.Create(partial)
+		require.NoError(t, err)
+
+		rejected, err := userSession.Create(partial)
+		require.ErrorIs(t, err, errs.ErrUserSessionLimit)
+		require.Equal(t, model.EmptyUserSession, rejected)
+
+		_, err = userSession.Delete(first.ID)
+		require.NoError(t, err)
+
+		_, err = userSession.Create(partial)
+		require.NoError(t, err)
+	})
+
+	t.Run("EvictByRole", func(t *testing.T) {
+		t.Parallel()
+
+		userSession := newUserSession(core.SessionLimit{
+			Default: 1,
+			Roles:   map[string]int{roleTemp.Name: 3},
+			Evict:   true,
+		})
+
+		userInput := model.UserPartial{
+			Name:               gofakeit.Name(),
+			Username:           gofakeit.Username(),
+			Email:              gofakeit.Email(),
+			Password:           gofakeit.Password(true, true, true, true, true, 20),
+			Roles:              []string{roleTemp.Name},
+			MustChangePassword: false,
+		}
+
+		_, err := user.Create(model.NewID(), userInput)
+		require.NoError(t, err)
+
+		partial := model.UserSessionPartial{ //nolint:exhaustruct
+			Username: userInput.Username,
+			Password: userInput.Password,
+		}
+
+		sessions := make([]model.UserSession, 0, 5)
+
+		for i := 0; i < 5; i++ {
+			userSessionTemp, err := userSession.Create(partial)
+			require.NoError(t, err)
+
+			sessions = append(sessions, userSessionTemp)
+		}
+
+		for _, evicted := range sessions[:2] {
+			_, err = userSession.GetByID(evicted.ID)
+			require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+		}
+
+		for _, active := range sessions[2:] {
+			_, err = userSession.GetByID(active.ID)
+			require.NoError(t, err)
+		}
+	})
+}
+
+func TestUserSessionRememberMe(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_remember_me")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Minute,
+		time.Hour,
+		time.Hour*24,
+		time.Hour*24*7,
+		time.Hour*24,
+		0,
+		noSessionLimit(),
+	)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	_, _, userInput := createTempUser(t, user, db, []string{})
+
+	partial := model.UserSessionPartial{ //nolint:exhaustruct
+		Username: userInput.Username,
+		Password: userInput.Password,
+	}
+
+	regular, err := userSession.Create(partial)
+	require.NoError(t, err)
+	require.False(t, regular.RememberMe)
+	require.WithinDuration(t, time.Now().Add(time.Minute), regular.Expires, time.Second)
+
+	partial.RememberMe = true
+
+	remembered, err := userSession.Create(partial)
+	require.NoError(t, err)
+	require.True(t, remembered.RememberMe)
+	require.WithinDuration(t, time.Now().Add(time.Hour*24), remembered.Expires, time.Second)
+
+	refreshed, err := userSession.Refresh(remembered.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, remembered.ID, refreshed.ID)
+	require.True(t, refreshed.RememberMe)
+	require.WithinDuration(t, time.Now().Add(time.Hour*24), refreshed.Expires, time.Second)
+
+	time.Sleep(2 * time.Second)
+
+	actives, err := items(userSession.GetAllActive(model.FirstPage(10)))
+	require.NoError(t, err)
+
+	for _, active := range actives {
+		require.Equal(t, active.ID == refreshed.ID, active.RememberMe)
+	}
+}
+
+func TestUserSessionRememberMeLifetime(t *testing.T) {
+	t.Parallel()
+
+	sessions := newMemorySessions()
+	userSession := core.NewUserSession(
+		sessions,
+		nil,
+		model.Validate(),
+		time.Minute,
+		time.Hour,
+		time.Hour*24,
+		time.Hour*48,
+		time.Hour*24,
+		0,
+		noSessionLimit(),
+	)
+
+	created := createMemorySession(t, sessions, time.Minute)
+	created.RememberMe = true
+	created.OriginalCreatedAt = time.Now().Add(-time.Hour * 36)
+
+	err := sessions.Create(created)
+	require.NoError(t, err)
+
+	refreshed, err := userSession.Refresh(created.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, created.ID, refreshed.ID)
+	require.True(t, refreshed.RememberMe)
+	require.True(t, refreshed.Expires.Equal(created.OriginalCreatedAt.Add(time.Hour*48)))
+}
+
+func TestUserSessionReauthenticate(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_reauthenticate")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		0,
+		noSessionLimit(),
+	)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	_, _, userInput := createTempUser(t, user, db, []string{})
+
+	created, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: userInput.Username,
+		Password: userInput.Password,
+	})
+	require.NoError(t, err)
+	require.Equal(t, model.AuthenticationPassword, created.AuthenticationMethod)
+	require.WithinDuration(t, time.Now(), created.AuthenticatedAt, time.Second)
+
+	time.Sleep(time.Second)
+
+	refreshed, err := userSession.Refresh(created.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, created.ID, refreshed.ID)
+	require.True(t, created.AuthenticatedAt.Equal(refreshed.AuthenticatedAt))
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		_, err := userSession.Reauthenticate(refreshed.ID, model.Reauthentication{
+			Password: gofakeit.Password(true, true, true, true, true, 20),
+		})
+		require.ErrorIs(t, err, errs.ErrPasswordDoesNotMatch)
+
+		_, err = userSession.Reauthenticate(refreshed.ID, model.Reauthentication{Password: ""})
+		require.ErrorAs(t, err, &core.InvalidError{})
+
+		_, err = userSession.Reauthenticate(model.NewID(), model.Reauthentication{
+			Password: userInput.Password,
+		})
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+
+	t.Run("ValidPassword", func(t *testing.T) {
+		reauthenticated, err := userSession.Reauthenticate(refreshed.ID, model.Reauthentication{
+			Password: userInput.Password,
+		})
+		require.NoError(t, err)
+		require.NotEqual(t, refreshed.ID, reauthenticated.ID)
+		require.True(t, reauthenticated.AuthenticatedAt.After(refreshed.AuthenticatedAt))
+		require.Equal(t, model.AuthenticationReauthentication, reauthenticated.AuthenticationMethod)
+		require.True(t, reauthenticated.OriginalCreatedAt.Equal(created.OriginalCreatedAt))
+
+		_, err = userSession.GetByID(refreshed.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+}
+
+func TestUserSessionImpersonate(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_impersonate")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		0,
+		noSessionLimit(),
+	)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	_, supportRole := createTempRole(t, role, db)
+
+	supportInput := model.UserPartial{ //nolint:exhaustruct
+		Name:     gofakeit.Name(),
+		Username: gofakeit.Username(),
+		Email:    gofakeit.Email(),
+		Password: gofakeit.Password(true, true, true, true, true, 20),
+		Roles:    []string{supportRole.Name},
+	}
+
+	supportID, err := user.Create(model.EmptyID, supportInput)
+	require.NoError(t, err)
+
+	targetID, _, _ := createTempUser(t, user, db, []string{})
+	_, _, otherInput := createTempUser(t, user, db, []string{})
+
+	supportSession, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: supportInput.Username,
+		Password: supportInput.Password,
+	})
+	require.NoError(t, err)
+
+	otherSession, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: otherInput.Username,
+		Password: otherInput.Password,
+	})
+	require.NoError(t, err)
+
+	t.Run("Denied", func(t *testing.T) {
+		_, err := userSession.Impersonate(otherSession.ID, targetID, supportRole.Name)
+		require.ErrorIs(t, err, errs.ErrImpersonationDenied)
+
+		_, err = userSession.Impersonate(supportSession.ID, targetID, "")
+		require.ErrorIs(t, err, errs.ErrImpersonationDenied)
+
+		_, err = userSession.Impersonate(supportSession.ID, model.NewID(), supportRole.Name)
+		require.ErrorIs(t, err, errs.ErrUserNotFound)
+
+		_, err = userSession.Impersonate(model.NewID(), targetID, supportRole.Name)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		_, err = userSession.StopImpersonation(supportSession.ID)
+		require.ErrorIs(t, err, errs.ErrNotImpersonating)
+	})
+
+	t.Run("Impersonate", func(t *testing.T) {
+		impersonated, err := userSession.Impersonate(supportSession.ID, targetID, supportRole.Name)
+		require.NoError(t, err)
+		require.Equal(t, targetID, impersonated.UserID)
+		require.Equal(t, supportID, impersonated.ImpersonatorID)
+		require.Equal(t, model.AuthenticationImpersonation, impersonated.AuthenticationMethod)
+		require.True(t, impersonated.AuthenticatedAt.IsZero())
+
+		found, err := userSession.GetByID(impersonated.ID)
+		require.NoError(t, err)
+		require.Equal(t, supportID, found.ImpersonatorID)
+
+		_, err = userSession.Impersonate(impersonated.ID, supportID, supportRole.Name)
+		require.ErrorIs(t, err, errs.ErrNestedImpersonation)
+
+		stopped, err := userSession.StopImpersonation(impersonated.ID)
+		require.NoError(t, err)
+		require.Equal(t, impersonated.ID, stopped.ID)
+
+		_, err = userSession.GetByID(impersonated.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		_, err = userSession.GetByID(supportSession.ID)
+		require.NoError(t, err)
+	})
+}
+
+func TestUserSessionImpersonateLimit(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_impersonate_limit")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		0,
+		core.SessionLimit{Default: 1, Roles: map[string]int{}, Evict: true},
+	)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	_, supportRole := createTempRole(t, role, db)
+
+	supportInput := model.UserPartial{ //nolint:exhaustruct
+		Name:     gofakeit.Name(),
+		Username: gofakeit.Username(),
+		Email:    gofakeit.Email(),
+		Password: gofakeit.Password(true, true, true, true, true, 20),
+		Roles:    []string{supportRole.Name},
+	}
+
+	supportID, err := user.Create(model.EmptyID, supportInput)
+	require.NoError(t, err)
+
+	targetID, _, targetInput := createTempUser(t, user, db, []string{})
+
+	supportSession, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: supportInput.Username,
+		Password: supportInput.Password,
+	})
+	require.NoError(t, err)
+
+	targetSession, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: targetInput.Username,
+		Password: targetInput.Password,
+	})
+	require.NoError(t, err)
+
+	impersonated, err := userSession.Impersonate(supportSession.ID, targetID, supportRole.Name)
+	require.NoError(t, err)
+
+	_, err = userSession.GetByID(targetSession.ID)
+	require.NoError(t, err)
+
+	newTargetSession, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: targetInput.Username,
+		Password: targetInput.Password,
+	})
+	require.NoError(t, err)
+
+	_, err = userSession.GetByID(targetSession.ID)
+	require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+	_, err = userSession.GetByID(impersonated.ID)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		targetSessions, err := items(userSession.GetByUserIDActive(targetID, model.FirstPage(10)))
+		if err != nil || len(targetSessions) != 1 {
+			return false
+		}
+
+		supportSessions, err := items(userSession.GetByUserIDActive(supportID, model.FirstPage(10)))
+		if err != nil || len(supportSessions) != 2 {
+			return false
+		}
+
+		return targetSessions[0].ID == newTargetSession.ID
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestUserSessionChangePassword(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "user_session_change_password")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(
+		data.NewUserSQL(db),
+		role,
+		model.Validate(),
+		fastPasswordHash(),
+		nil,
+		0,
+		time.Hour,
+	)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
+	)
+
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
+
+	t.Run("NotExpired", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, userInput := createTempUser(t, user, db, []string{})
+
+		session, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+			Username: userInput.Username,
+			Password: userInput.Password,
+		})
+		require.NoError(t, err)
+		require.False(t, session.Restricted)
+
+		session, err = userSession.Refresh(session.ID)
+		require.NoError(t, err)
+		require.False(t, session.Restricted)
+	})
+
+	t.Run("ExpiresMidSession", func(t *testing.T) {
+		t.Parallel()
+
+		userID, _, userInput := createTempUser(t, user, db, []string{})
+
+		session, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+			Username: userInput.Username,
+			Password: userInput.Password,
+		})
+		require.NoError(t, err)
+		require.False(t, session.Restricted)
+
+		update := model.UserUpdate{MustChangePassword: boolPointer(true)} //nolint:exhaustruct
+		err = user.Update(userID, model.AnyVersion, update)
+		require.NoError(t, err)
+
+		session, err = userSession.Refresh(session.ID)
+		require.NoError(t, err)
+		require.True(t, session.Restricted)
+	})
+
+	t.Run("MaxAgeMidSession", func(t *testing.T) {
+		t.Parallel()
+
+		shortUser := core.NewUser(
+			data.NewUserSQL(db),
+			role,
+			model.Validate(),
+			fastPasswordHash(),
+			nil,
+			0,
+			2*time.Second,
+		)
+		shortUserSession := core.NewUserSession(
+			userSessionRedis,
+			shortUser,
+			model.Validate(),
+			time.Minute,
+			time.Hour,
+			time.Minute,
+			time.Hour,
+			time.Second,
+			0,
+			noSessionLimit(),
+		)
+
+		_, _, userInput := createTempUser(t, shortUser, db, []string{})
+
+		session, err := shortUserSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+			Username: userInput.Username,
+			Password: userInput.Password,
+		})
+		require.NoError(t, err)
+		require.False(t, session.Restricted)
+
+		time.Sleep(2 * time.Second)
 
-					userSessionTemp, err := userSession.Create(input.input)
-					require.NoError(t, err)
+		session, err = shortUserSession.Refresh(session.ID)
+		require.NoError(t, err)
+		require.True(t, session.Restricted)
+	})
 
-					require.Equal(t, userID, userSessionTemp.UserID)
-					require.LessOrEqual(t, time.Since(userSessionTemp.CreateaAt), time.Second)
-					require.Equal(t, time.Time{}, userSessionTemp.DeletedAt)
+	t.Run("MustChangePassword", func(t *testing.T) {
+		t.Parallel()
 
-					userSessionDB, err := userSession.GetByID(userSessionTemp.ID)
-					require.NoError(t, err)
+		userInput := model.UserPartial{
+			Name:               gofakeit.Name(),
+			Username:           gofakeit.Username(),
+			Email:              gofakeit.Email(),
+			Password:           gofakeit.Password(true, true, true, true, true, 20),
+			Roles:              []string{},
+			MustChangePassword: true,
+		}
 
-					require.Equal(t, userSessionDB.ID, userSessionTemp.ID)
-					require.Equal(t, userSessionDB.UserID, userSessionTemp.UserID)
-					require.True(t, userSessionDB.CreateaAt.Equal(userSessionTemp.CreateaAt))
-					require.True(t, userSessionDB.DeletedAt.Equal(userSessionTemp.DeletedAt))
-				})
-			}
+		userID, err := user.Create(model.NewID(), userInput)
+		require.NoError(t, err)
+
+		session, err := userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+			Username: userInput.Username,
+			Password: userInput.Password,
 		})
+		require.NoError(t, err)
+		require.True(t, session.Restricted)
 
-		t.Run("InvalidInputs", func(t *testing.T) {
-			t.Parallel()
+		session, err = userSession.Refresh(session.ID)
+		require.NoError(t, err)
+		require.True(t, session.Restricted)
 
-			//nolint:exhaustruct
-			inputs := []struct {
-				name  string
-				input model.UserSessionPartial
-			}{
-				{
-					"UsernameWithEmail",
-					model.UserSessionPartial{
-						Email:    userInput.Email,
-						Username: userInput.Email,
-						Password: userInput.Password,
-					},
-				},
-				{
-					"UsernameWhitoutPassword",
-					model.UserSessionPartial{Username: userInput.Username},
-				},
-				{
-					"EmailWhitoutPassword",
-					model.UserSessionPartial{Email: userInput.Email},
-				},
-			}
+		newPassword := gofakeit.Password(true, true, true, true, true, 20)
 
-			for _, input := range inputs {
-				input := input
+		_, err = userSession.ChangePassword(session.ID, model.PasswordUpdate{
+			CurrentPassword: newPassword,
+			NewPassword:     newPassword,
+		})
+		require.ErrorIs(t, err, errs.ErrPasswordDoesNotMatch)
 
-				t.Run(input.name, func(t *testing.T) {
-					t.Parallel()
+		_, err = userSession.ChangePassword(session.ID, model.PasswordUpdate{
+			CurrentPassword: userInput.Password,
+			NewPassword:     "",
+		})
+		require.ErrorAs(t, err, &core.InvalidError{})
 
-					userSessionCreated, err := userSession.Create(input.input)
-					require.ErrorAs(t, err, &core.InvalidError{})
-					require.Equal(t, userSessionCreated, model.EmptyUserSession)
-				})
-			}
+		newSession, err := userSession.ChangePassword(session.ID, model.PasswordUpdate{
+			CurrentPassword: userInput.Password,
+			NewPassword:     newPassword,
 		})
+		require.NoError(t, err)
+		require.False(t, newSession.Restricted)
+		require.Equal(t, userID, newSession.UserID)
+		require.NotEqual(t, session.ID, newSession.ID)
 
-		t.Run("WrongPassword", func(t *testing.T) {
-			t.Parallel()
-			//nolint:exhaustruct
-			inputs := []struct {
-				name  string
-				input model.UserSessionPartial
-			}{
-				{
-					"Username",
-					model.UserSessionPartial{
-						Username: userInput.Username,
-						Password: gofakeit.Password(true, true, true, true, true, 20),
-					},
-				},
-				{
-					"Email",
-					model.UserSessionPartial{
-						Email:    userInput.Email,
-						Password: gofakeit.Password(true, true, true, true, true, 20),
-					},
-				},
-			}
+		_, err = userSession.GetByID(session.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
 
-			for _, input := range inputs {
-				input := input
+		userdb, err := user.GetByID(userID)
+		require.NoError(t, err)
+		require.False(t, userdb.MustChangePassword)
+		require.LessOrEqual(t, time.Since(userdb.PasswordChangedAt), time.Second)
 
-				t.Run(input.name, func(t *testing.T) {
-					t.Parallel()
+		match, err := user.EqualPassword(newPassword, userdb.Password)
+		require.NoError(t, err)
+		require.True(t, match)
+	})
 
-					userSessionCreated, err := userSession.Create(input.input)
-					require.ErrorIs(t, err, errs.ErrPasswordDoesNotMatch)
-					require.Equal(t, userSessionCreated, model.EmptyUserSession)
-				})
-			}
+	t.Run("UserSessionNotFound", func(t *testing.T) {
+		t.Parallel()
+
+		session, err := userSession.ChangePassword(model.NewID(), model.PasswordUpdate{
+			CurrentPassword: gofakeit.Password(true, true, true, true, true, 20),
+			NewPassword:     gofakeit.Password(true, true, true, true, true, 20),
 		})
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+		require.Equal(t, model.EmptyUserSession, session)
+	})
+}
 
-		t.Run("UserNotFound", func(t *testing.T) {
-			t.Parallel()
-			//nolint:exhaustruct
-			inputs := []struct {
-				name  string
-				input model.UserSessionPartial
-			}{
-				{
-					"Username",
-					model.UserSessionPartial{
-						Username: gofakeit.Username(),
-						Password: gofakeit.Password(true, true, true, true, true, 20),
-					},
-				},
-				{
-					"Email",
-					model.UserSessionPartial{
-						Email:    gofakeit.Email(),
-						Password: gofakeit.Password(true, true, true, true, true, 20),
-					},
-				},
-			}
+func TestUserSessionRehash(t *testing.T) {
+	t.Parallel()
 
-			for _, input := range inputs {
-				input := input
+	db := createTempDB(t, "user_session_rehash")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 30
 
-				t.Run(input.name, func(t *testing.T) {
-					t.Parallel()
+	hashes := cheapPasswordHashes()
 
-					userSessionCreated, err := userSession.Create(input.input)
-					require.ErrorIs(t, err, errs.ErrUserNotFound)
-					require.Equal(t, userSessionCreated, model.EmptyUserSession)
-				})
-			}
-		})
-	}
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	oldUser := core.NewUser(data.NewUserSQL(db), role, model.Validate(), hashes[1], nil, 0, 0)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), hashes[0], nil, 0, 0)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
+	)
 
-	t.Run("UserSeesionNotExist", func(t *testing.T) {
-		t.Parallel()
+	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+	logErros(t, userSessionRedis.Errors())
 
-		userSession, err := userSession.GetByID(model.NewID())
-		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
-		require.Equal(t, userSession, model.EmptyUserSession)
+	userID, _, userInput := createTempUser(t, oldUser, db, []string{})
+
+	userdb, err := user.GetByID(userID)
+	require.NoError(t, err)
+	require.True(t, user.NeedsRehash(userdb.Password))
+
+	_, err = userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: userInput.Username,
+		Password: gofakeit.Password(true, true, true, true, true, 20),
+	})
+	require.ErrorIs(t, err, errs.ErrPasswordDoesNotMatch)
+
+	userdb, err = user.GetByID(userID)
+	require.NoError(t, err)
+	require.True(t, user.NeedsRehash(userdb.Password))
+
+	_, err = userSession.Create(model.UserSessionPartial{ //nolint:exhaustruct
+		Username: userInput.Username,
+		Password: userInput.Password,
 	})
+	require.NoError(t, err)
+
+	rehashed, err := user.GetByID(userID)
+	require.NoError(t, err)
+	require.False(t, user.NeedsRehash(rehashed.Password))
+	require.True(t, rehashed.PasswordChangedAt.Equal(userdb.PasswordChangedAt))
+
+	match, err := user.EqualPassword(userInput.Password, rehashed.Password)
+	require.NoError(t, err)
+	require.True(t, match)
 }
 
-func TestUserSessionRefresh(t *testing.T) {
+func TestUserSessionMetadata(t *testing.T) {
 	t.Parallel()
 
-	db := createTempDB(t, "user_session_refresh")
+	db := createTempDB(t, "user_session_metadata")
 	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
 		Addr:     "localhost:6379",
 		Password: "redis",
 		DB:       0,
 	})
 	buffer := 30
-	overflowBuffer := buffer * 10
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
 	userSession := core.NewUserSession(
 		userSessionRedis,
 		user,
 		model.Validate(),
 		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
 	)
 
 	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
 	require.NoError(t, err)
 	logErros(t, userSessionRedis.Errors())
 
-	qtRoles := 5
-	rolesTemp := make([]string, qtRoles)
+	userID, _, userInput := createTempUser(t, user, db, []string{})
 
-	for i := range rolesTemp {
-		_, role := createTempRole(t, role, db)
-		rolesTemp[i] = role.Name
-	}
+	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 " +
+		"(KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36"
 
-	qtUsers := overflowBuffer
-	usersTemp := make([]partialUser, qtUsers)
+	created, err := userSession.Create(model.UserSessionPartial{
+		Username:   userInput.Username,
+		Email:      "",
+		Password:   userInput.Password,
+		DeviceName: "work laptop",
+		IP:         "203.0.113.7",
+		UserAgent:  userAgent,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.7", created.IP)
+	require.Equal(t, userAgent, created.UserAgent)
+	require.Equal(t, "Chrome on Windows", created.Device)
+	require.Equal(t, "work laptop", created.DeviceName)
 
-	for i := 0; i < qtUsers; i++ {
-		userid, createdBy, userTemp := createTempUser(t, user, db, rolesTemp)
-		usersTemp[i] = partialUser{
-			userID:    userid,
-			input:     userTemp,
-			createdBy: createdBy,
-			deletedBy: model.NewID(),
-		}
-	}
+	refreshed, err := userSession.Refresh(created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created.IP, refreshed.IP)
+	require.Equal(t, created.UserAgent, refreshed.UserAgent)
+	require.Equal(t, created.Device, refreshed.Device)
+	require.Equal(t, created.DeviceName, refreshed.DeviceName)
 
-	for _, userTemp := range usersTemp {
-		userID, userInput := userTemp.userID, userTemp.input
+	time.Sleep(2 * time.Second)
 
-		t.Run("ValiInputs", func(t *testing.T) {
-			t.Parallel()
+	actives, err := items(userSession.GetByUserIDActive(userID, model.FirstPage(10)))
+	require.NoError(t, err)
+	require.Len(t, actives, 1)
+	require.Equal(t, refreshed.Device, actives[0].Device)
+	require.Equal(t, refreshed.DeviceName, actives[0].DeviceName)
+
+	_, err = userSession.Create(model.UserSessionPartial{
+		Username:   userInput.Username,
+		Email:      "",
+		Password:   userInput.Password,
+		DeviceName: gofakeit.LetterN(256),
+		IP:         "",
+		UserAgent:  "",
+	})
+	require.ErrorAs(t, err, &core.InvalidError{})
+}
 
-			UserSessionPartial := model.UserSessionPartial{ //nolint:exhaustruct
-				Username: userInput.Username,
-				Password: userInput.Password,
-			}
+func BenchmarkUserSessionCreate(b *testing.B) {
+	db := createTempDB(b, "user_session_benchmark")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := b.N + 100
 
-			userSessionTemp1, err := userSession.Create(UserSessionPartial)
-			require.NoError(t, err)
+	pool, err := core.NewPasswordPool(runtime.NumCPU(), b.N, prometheus.NewRegistry())
+	require.NoError(b, err)
 
-			userSessionTemp2, err := userSession.Refresh(userSessionTemp1.ID)
-			require.NoError(t, err)
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(
+		data.NewUserSQL(db),
+		role,
+		model.Validate(),
+		core.DefaultPasswordHash(),
+		pool,
+		0,
+		0,
+	)
+	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
+	userSession := core.NewUserSession(
+		userSessionRedis,
+		user,
+		model.Validate(),
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		time.Hour,
+		time.Minute,
+		0,
+		noSessionLimit(),
+	)
 
-			require.NotEqual(t, userSessionTemp1.ID, userSessionTemp2.ID)
-			require.Equal(t, userID, userSessionTemp2.UserID)
-			require.LessOrEqual(t, time.Since(userSessionTemp2.CreateaAt), time.Second)
-			require.Equal(t, time.Time{}, userSessionTemp2.DeletedAt)
-		})
+	err = userSessionRedis.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(b, err)
 
-		t.Run("UserSessionNotFound", func(t *testing.T) {
-			t.Parallel()
+	_, _, userInput := createTempUser(b, user, db, []string{})
 
-			userSessionTemp, err := userSession.Refresh(model.NewID())
-			require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
-			require.Equal(t, userSessionTemp, model.EmptyUserSession)
-		})
+	partial := model.UserSessionPartial{ //nolint:exhaustruct
+		Username: userInput.Username,
+		Password: userInput.Password,
 	}
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, err := userSession.Create(partial)
+			if err != nil {
+				b.Error(err)
+			}
+		}
+	})
 }
 
 func TestUserSessionDelete(t *testing.T) {
@@ -338,13 +1846,19 @@ func TestUserSessionDelete(t *testing.T) {
 	overflowBuffer := buffer * 10
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
 	userSession := core.NewUserSession(
 		userSessionRedis,
 		user,
 		model.Validate(),
 		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
 	)
 
 	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
@@ -418,13 +1932,19 @@ func TestUserSessionGetAll(t *testing.T) { //nolint:funlen
 	overflowBuffer := buffer * 10
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
 	userSession := core.NewUserSession(
 		userSessionRedis,
 		user,
 		model.Validate(),
 		time.Second*10,
+		time.Hour,
+		time.Second*10,
+		time.Hour,
+		time.Second*10,
+		0,
+		noSessionLimit(),
 	)
 
 	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
@@ -443,11 +1963,11 @@ func TestUserSessionGetAll(t *testing.T) { //nolint:funlen
 	usersID := make([]model.ID, 0, qtUsersSessions)
 	usersSessionsID := make([]model.ID, 0, qtUsersSessions)
 
-	usersSessionsActive, err := userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsActive, err := items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsActive)
 
-	usersSessionsInactive, err := userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsInactive, err := items(userSession.GetAllInactive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsInactive)
 
@@ -467,11 +1987,11 @@ func TestUserSessionGetAll(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second)
 
-	usersSessionsActive, err = userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsActive, err = items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsActive))
 
-	usersSessionsInactive, err = userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsInactive, err = items(userSession.GetAllInactive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsInactive)
 
@@ -496,11 +2016,11 @@ func TestUserSessionGetAll(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second)
 
-	usersSessionsActive, err = userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsActive, err = items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsActive)
 
-	usersSessionsInactive, err = userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsInactive, err = items(userSession.GetAllInactive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsInactive))
 
@@ -534,13 +2054,19 @@ func TestUserSessionGetByUserID(t *testing.T) { //nolint:funlen
 	overflowBuffer := buffer * 10
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 	userSessionRedis := data.NewUserSessionRedis(redisClient, db, buffer)
 	userSession := core.NewUserSession(
 		userSessionRedis,
 		user,
 		model.Validate(),
 		time.Second*10,
+		time.Hour,
+		time.Second*10,
+		time.Hour,
+		time.Second*10,
+		0,
+		noSessionLimit(),
 	)
 
 	err := userSessionRedis.ConsumeQueues(time.Second, buffer/2)
@@ -564,11 +2090,17 @@ func TestUserSessionGetByUserID(t *testing.T) { //nolint:funlen
 		Password: userTemp.Password,
 	}
 
-	usersSessionsActive, err := userSession.GetByUserIDActive(userID, 0, qtUserSessions)
+	usersSessionsActive, err := items(userSession.GetByUserIDActive(
+		userID,
+		model.FirstPage(qtUserSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsActive)
 
-	usersSessionsInactive, err := userSession.GetByUserIDInactive(userID, 0, qtUserSessions)
+	usersSessionsInactive, err := items(userSession.GetByUserIDInactive(
+		userID,
+		model.FirstPage(qtUserSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsInactive)
 
@@ -581,11 +2113,17 @@ func TestUserSessionGetByUserID(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second)
 
-	usersSessionsActive, err = userSession.GetByUserIDActive(userID, 0, qtUserSessions)
+	usersSessionsActive, err = items(userSession.GetByUserIDActive(
+		userID,
+		model.FirstPage(qtUserSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, qtUserSessions, len(usersSessionsActive))
 
-	usersSessionsInactive, err = userSession.GetByUserIDInactive(userID, 0, qtUserSessions)
+	usersSessionsInactive, err = items(userSession.GetByUserIDInactive(
+		userID,
+		model.FirstPage(qtUserSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsInactive)
 
@@ -610,11 +2148,17 @@ func TestUserSessionGetByUserID(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second)
 
-	usersSessionsActive, err = userSession.GetByUserIDActive(userID, 0, qtUserSessions)
+	usersSessionsActive, err = items(userSession.GetByUserIDActive(
+		userID,
+		model.FirstPage(qtUserSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsActive)
 
-	usersSessionsInactive, err = userSession.GetByUserIDInactive(userID, 0, qtUserSessions)
+	usersSessionsInactive, err = items(userSession.GetByUserIDInactive(
+		userID,
+		model.FirstPage(qtUserSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, qtUserSessions, len(usersSessionsInactive))
 
@@ -638,19 +2182,25 @@ func TestUserSessionGetByUserID(t *testing.T) { //nolint:funlen
 func checkUserSessionWrongDB(t *testing.T, userSession *core.UserSession, qtUserSessions int) {
 	t.Helper()
 
-	userSessions, err := userSession.GetAllActive(0, qtUserSessions)
+	userSessions, err := items(userSession.GetAllActive(model.FirstPage(qtUserSessions)))
 	require.ErrorContains(t, err, "no such host")
 	assert.Equal(t, model.EmptyUserSessions, userSessions)
 
-	userSessions, err = userSession.GetAllInactive(0, qtUserSessions)
+	userSessions, err = items(userSession.GetAllInactive(model.FirstPage(qtUserSessions)))
 	require.ErrorContains(t, err, "no such host")
 	assert.Equal(t, model.EmptyUserSessions, userSessions)
 
-	userSessions, err = userSession.GetByUserIDActive(model.NewID(), 0, qtUserSessions)
+	userSessions, err = items(userSession.GetByUserIDActive(
+		model.NewID(),
+		model.FirstPage(qtUserSessions),
+	))
 	require.ErrorContains(t, err, "no such host")
 	assert.Equal(t, model.EmptyUserSessions, userSessions)
 
-	userSessions, err = userSession.GetByUserIDInactive(model.NewID(), 0, qtUserSessions)
+	userSessions, err = items(userSession.GetByUserIDInactive(
+		model.NewID(),
+		model.FirstPage(qtUserSessions),
+	))
 	require.ErrorContains(t, err, "no such host")
 	assert.Equal(t, model.EmptyUserSessions, userSessions)
 
@@ -684,8 +2234,24 @@ func TestUserSessionWrongDB(t *testing.T) {
 		rolesValid[i] = role.Name
 	}
 
-	user1 := core.NewUser(data.NewUserSQL(dbValid), role1, model.Validate(), true)
-	user2 := core.NewUser(data.NewUserSQL(db), role2, model.Validate(), true)
+	user1 := core.NewUser(
+		data.NewUserSQL(dbValid),
+		role1,
+		model.Validate(),
+		core.DefaultPasswordHash(),
+		nil,
+		0,
+		0,
+	)
+	user2 := core.NewUser(
+		data.NewUserSQL(db),
+		role2,
+		model.Validate(),
+		core.DefaultPasswordHash(),
+		nil,
+		0,
+		0,
+	)
 
 	inputUser := model.UserPartial{
 		Name:     gofakeit.Name(),
@@ -714,6 +2280,12 @@ func TestUserSessionWrongDB(t *testing.T) {
 		user1,
 		model.Validate(),
 		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
 	)
 	err := userSessionRedis1.ConsumeQueues(time.Second, buffer)
 	assert.NoError(t, err)
@@ -723,6 +2295,12 @@ func TestUserSessionWrongDB(t *testing.T) {
 		user1,
 		model.Validate(),
 		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
 	)
 
 	userSession3 := core.NewUserSession(
@@ -730,6 +2308,12 @@ func TestUserSessionWrongDB(t *testing.T) {
 		user2,
 		model.Validate(),
 		time.Second,
+		time.Hour,
+		time.Second,
+		time.Hour,
+		time.Second,
+		0,
+		noSessionLimit(),
 	)
 
 	input := model.UserSessionPartial{ //nolint:exhaustruct
@@ -2,6 +2,8 @@ package core_test
 
 import (
 	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/thiago-felipe-99/autenticacao/data"
 	"github.com/thiago-felipe-99/autenticacao/errs"
 	"github.com/thiago-felipe-99/autenticacao/model"
+	"golang.org/x/text/unicode/norm"
 )
 
 var invalidUserPartialInputs = []struct { //nolint:gochecknoglobals
@@ -138,7 +141,7 @@ func randomSliceString(valids []string) []string {
 }
 
 func createTempUser(
-	t *testing.T,
+	t testing.TB,
 	user *core.User,
 	db *sqlx.DB,
 	validRoles []string,
@@ -173,7 +176,7 @@ func TestUserCreate(t *testing.T) {
 	db := createTempDB(t, "user_create")
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 
 	qtRoles := 10
 	roles := make([]string, qtRoles)
@@ -250,6 +253,84 @@ func TestUserCreate(t *testing.T) {
 			require.Equal(t, userCreated, model.EmptyID)
 		})
 	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		t.Parallel()
+
+		userID, _, input := createTempUser(t, user, db, roles)
+
+		err := user.Delete(userID, model.NewID())
+		require.NoError(t, err)
+
+		userCreated, err := user.Create(model.NewID(), input)
+		require.NoError(t, err)
+		require.NotEqual(t, userID, userCreated)
+
+		userdb, err := user.GetByEmail(input.Email)
+		require.NoError(t, err)
+		require.Equal(t, userCreated, userdb.ID)
+	})
+
+	t.Run("Normalized", func(t *testing.T) {
+		t.Parallel()
+
+		username := "ｕｓｅｒ_" + gofakeit.LetterN(10)
+		input := model.UserPartial{
+			Name:     gofakeit.Name(),
+			Username: username,
+			Email:    "User." + gofakeit.LetterN(10) + "@Example.com",
+			Password: gofakeit.Password(true, true, true, true, true, 20),
+			Roles:    roles,
+		}
+
+		userID, err := user.Create(model.NewID(), input)
+		require.NoError(t, err)
+
+		userdb, err := user.GetByID(userID)
+		require.NoError(t, err)
+		require.Equal(t, norm.NFKC.String(username), userdb.Username)
+		require.Equal(t, strings.ToLower(input.Email), userdb.Email)
+
+		userdb, err = user.GetByUsername(strings.ToUpper(norm.NFKC.String(username)))
+		require.NoError(t, err)
+		require.Equal(t, userID, userdb.ID)
+
+		userdb, err = user.GetByEmail(input.Email)
+		require.NoError(t, err)
+		require.Equal(t, userID, userdb.ID)
+
+		duplicate := input
+		duplicate.Username = strings.ToUpper(norm.NFKC.String(username))
+		duplicate.Email = gofakeit.Email()
+
+		_, err = user.Create(model.NewID(), duplicate)
+		require.ErrorIs(t, err, errs.ErrUsernameAlreadyExist)
+
+		duplicate = input
+		duplicate.Username = gofakeit.Username()
+		duplicate.Email = strings.ToUpper(input.Email)
+
+		_, err = user.Create(model.NewID(), duplicate)
+		require.ErrorIs(t, err, errs.ErrEmailAlreadyExist)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		input := model.UserPartial{
+			Name:     gofakeit.Name(),
+			Username: gofakeit.Username(),
+			Email:    gofakeit.Email(),
+			Password: gofakeit.Password(true, true, true, true, true, 20),
+			Roles:    roles,
+		}
+
+		requireOneWins(t, 20, errs.ErrUsernameAlreadyExist, func() error {
+			_, err := user.Create(model.NewID(), input)
+
+			return err
+		})
+	})
 }
 
 type partialUser struct {
@@ -262,6 +343,25 @@ type partialUser struct {
 func requireUser(t *testing.T, partial partialUser, userdb model.User, user *core.User) {
 	t.Helper()
 
+	requireUserFields(t, partial, userdb)
+
+	match, err := user.EqualPassword(partial.input.Password, userdb.Password)
+	require.NoError(t, err)
+	require.True(t, match)
+}
+
+// requireUserListed checks users returned by list queries, which never load the
+// password hash.
+func requireUserListed(t *testing.T, partial partialUser, userdb model.User) {
+	t.Helper()
+
+	requireUserFields(t, partial, userdb)
+	require.Empty(t, userdb.Password)
+}
+
+func requireUserFields(t *testing.T, partial partialUser, userdb model.User) {
+	t.Helper()
+
 	require.Equal(t, partial.userID, userdb.ID)
 	require.Equal(t, partial.input.Name, userdb.Name)
 	require.Equal(t, partial.input.Username, userdb.Username)
@@ -272,10 +372,6 @@ func requireUser(t *testing.T, partial partialUser, userdb model.User, user *cor
 	require.Equal(t, partial.createdBy, userdb.CreatedBy)
 	require.True(t, time.Time{}.Equal(userdb.DeletedAt))
 	require.Equal(t, model.EmptyID, userdb.DeletedBy)
-
-	match, err := user.EqualPassword(partial.input.Password, userdb.Password)
-	require.NoError(t, err)
-	require.True(t, match)
 }
 
 func TestUserGet(t *testing.T) { //nolint:funlen
@@ -284,7 +380,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 	db := createTempDB(t, "user_get")
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 
 	qtRoles := 10
 	roles := make([]string, qtRoles)
@@ -376,7 +472,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 	t.Run("GetAll", func(t *testing.T) {
 		t.Parallel()
 
-		usersdb, err := user.GetAll(0, qtUsers)
+		usersdb, err := items(user.GetAll(model.ExcludeDeleted, model.FirstPage(qtUsers)))
 		require.NoError(t, err)
 
 		require.Equal(t, qtUsers, len(usersdb))
@@ -386,7 +482,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 				return p.userID == userdb.ID
 			})
 
-			requireUser(t, usersTemp[index], userdb, user)
+			requireUserListed(t, usersTemp[index], userdb)
 		}
 	})
 
@@ -397,7 +493,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 		t.Run("GetByRole", func(t *testing.T) {
 			t.Parallel()
 
-			usersdb, err := user.GetByRole([]string{role}, 0, qtUsers)
+			usersdb, err := items(user.GetByRole([]string{role}, model.ExcludeDeleted, model.FirstPage(qtUsers)))
 			require.NoError(t, err)
 
 			require.Equal(t, sum, len(usersdb))
@@ -406,7 +502,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 				index := slices.IndexFunc(usersTemp, func(p partialUser) bool {
 					return p.userID == userdb.ID
 				})
-				requireUser(t, usersTemp[index], userdb, user)
+				requireUserListed(t, usersTemp[index], userdb)
 			}
 		})
 	}
@@ -426,7 +522,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 					sum := sum
 
 					t.Run(role2, func(t *testing.T) {
-						usersdb, err := user.GetByRole([]string{role1, role2}, 0, qtUsers)
+						usersdb, err := items(user.GetByRole([]string{role1, role2}, model.ExcludeDeleted, model.FirstPage(qtUsers)))
 						require.NoError(t, err)
 
 						require.Equal(t, sum, len(usersdb))
@@ -435,7 +531,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 							index := slices.IndexFunc(usersTemp, func(p partialUser) bool {
 								return p.userID == userdb.ID
 							})
-							requireUser(t, usersTemp[index], userdb, user)
+							requireUserListed(t, usersTemp[index], userdb)
 						}
 					})
 				}
@@ -446,7 +542,7 @@ func TestUserGet(t *testing.T) { //nolint:funlen
 	t.Run("GetByRole/RoleNotFound", func(t *testing.T) {
 		t.Parallel()
 
-		userFound, err := user.GetByRole([]string{gofakeit.Name()}, 0, qtUsers)
+		userFound, err := items(user.GetByRole([]string{gofakeit.Name()}, model.ExcludeDeleted, model.FirstPage(qtUsers)))
 		require.ErrorIs(t, err, errs.ErrRoleNotFound)
 		require.Equal(t, userFound, model.EmptyUsers)
 	})
@@ -503,13 +599,180 @@ func requireUserUpdate(
 	}
 }
 
+func TestUserExpand(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_expand")
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+
+	qtRoles := 10
+	roles := make([]string, qtRoles)
+
+	for i := range roles {
+		_, role := createTempRole(t, role, db)
+		roles[i] = role.Name
+	}
+
+	creatorID, _, creatorInput := createTempUser(t, user, db, roles)
+
+	users := make([]model.User, 0, qtRoles)
+
+	for i := 0; i < qtRoles; i++ {
+		userID, err := user.Create(creatorID, model.UserPartial{ //nolint:exhaustruct
+			Name:     gofakeit.Name(),
+			Username: gofakeit.Username(),
+			Email:    gofakeit.Email(),
+			Password: gofakeit.Password(true, true, true, true, true, 20),
+			Roles:    randomSliceString(roles),
+		})
+		require.NoError(t, err)
+
+		userdb, err := user.GetByID(userID)
+		require.NoError(t, err)
+
+		users = append(users, userdb)
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		expansions, err := user.Expand(users, []string{})
+		require.NoError(t, err)
+		require.Nil(t, expansions.Roles)
+		require.Nil(t, expansions.Users)
+	})
+
+	t.Run("All", func(t *testing.T) {
+		t.Parallel()
+
+		expansions, err := user.Expand(users, []string{model.ExpandRoles, model.ExpandCreatedBy})
+		require.NoError(t, err)
+
+		for _, userdb := range users {
+			for _, roleName := range userdb.Roles {
+				require.Equal(t, roleName, expansions.Roles[roleName].Name)
+			}
+
+			creator, ok := expansions.Users[userdb.CreatedBy]
+			require.True(t, ok)
+			require.Equal(t, creatorID, creator.ID)
+			require.Equal(t, creatorInput.Username, creator.Username)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		expansions, err := user.Expand(users, []string{model.ExpandRoles, "password"})
+		require.ErrorIs(t, err, errs.ErrInvalidExpand)
+		require.Equal(t, model.EmptyUserExpansions, expansions)
+	})
+}
+
+func TestUserSearch(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_search")
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+
+	qtRoles := 10
+	roles := make([]string, qtRoles)
+
+	for i := range roles {
+		_, role := createTempRole(t, role, db)
+		roles[i] = role.Name
+	}
+
+	creatorID, _, _ := createTempUser(t, user, db, roles)
+
+	qtUsers := 10
+	usernames := make([]string, 0, qtUsers)
+
+	for i := 0; i < qtUsers; i++ {
+		input := model.UserPartial{ //nolint:exhaustruct
+			Name:     gofakeit.Name(),
+			Username: gofakeit.Username(),
+			Email:    gofakeit.Email(),
+			Password: gofakeit.Password(true, true, true, true, true, 20),
+			Roles:    randomSliceString(roles),
+		}
+
+		_, err := user.Create(creatorID, input)
+		require.NoError(t, err)
+
+		usernames = append(usernames, input.Username)
+	}
+
+	t.Run("CreatedBySorted", func(t *testing.T) {
+		t.Parallel()
+
+		filter := model.UserFilter{ //nolint:exhaustruct
+			CreatedBy: creatorID,
+			Sort:      []model.UserSort{{Field: model.UserSortUsername, Descending: false}},
+		}
+
+		users, err := items(user.Search(filter, model.FirstPage(qtUsers*2)))
+		require.NoError(t, err)
+		require.Len(t, users, qtUsers)
+
+		found := make([]string, 0, len(users))
+		for _, userdb := range users {
+			require.Equal(t, creatorID, userdb.CreatedBy)
+			require.Empty(t, userdb.Password)
+
+			found = append(found, userdb.Username)
+		}
+
+		require.ElementsMatch(t, usernames, found)
+
+		filter.Sort[0].Descending = true
+
+		users, err = items(user.Search(filter, model.FirstPage(qtUsers*2)))
+		require.NoError(t, err)
+		require.Len(t, users, qtUsers)
+
+		for i, userdb := range users {
+			require.Equal(t, found[len(found)-1-i], userdb.Username)
+		}
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		t.Parallel()
+
+		filter := model.UserFilter{ //nolint:exhaustruct
+			Sort: []model.UserSort{{Field: "password", Descending: true}},
+		}
+
+		users, err := items(user.Search(filter, model.FirstPage(qtUsers)))
+		require.ErrorIs(t, err, errs.ErrInvalidSort)
+		require.Equal(t, model.EmptyUsers, users)
+	})
+
+	t.Run("InvalidCreatedRange", func(t *testing.T) {
+		t.Parallel()
+
+		filter := model.UserFilter{ //nolint:exhaustruct
+			CreatedFrom: time.Now(),
+			CreatedTo:   time.Now().Add(-time.Hour),
+		}
+
+		users, err := items(user.Search(filter, model.FirstPage(qtUsers)))
+		require.ErrorIs(t, err, errs.ErrInvalidFilter)
+		require.Equal(t, model.EmptyUsers, users)
+	})
+}
+
 func TestUserUpdate(t *testing.T) {
 	t.Parallel()
 
 	db := createTempDB(t, "user_update")
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 
 	qtRoles := 10
 	roles := make([]string, qtRoles)
@@ -530,7 +793,7 @@ func TestUserUpdate(t *testing.T) {
 
 				userid, _, userTemp := createTempUser(t, user, db, roles)
 
-				err := user.Update(userid, test.input)
+				err := user.Update(userid, model.AnyVersion, test.input)
 				require.NoError(t, err)
 
 				requireUserUpdate(t, test.input, userTemp, userid, user)
@@ -545,7 +808,7 @@ func TestUserUpdate(t *testing.T) {
 
 		update := model.UserUpdate{Roles: randomSliceString(roles)} //nolint:exhaustruct
 
-		err := user.Update(userid, update)
+		err := user.Update(userid, model.AnyVersion, update)
 		require.NoError(t, err)
 
 		requireUserUpdate(t, update, userTemp, userid, user)
@@ -565,7 +828,7 @@ func TestUserUpdate(t *testing.T) {
 			IsActive: boolPointer(false),
 		}
 
-		err := user.Update(userid, update)
+		err := user.Update(userid, model.AnyVersion, update)
 		require.NoError(t, err)
 
 		requireUserUpdate(t, update, userTemp, userid, user)
@@ -580,7 +843,7 @@ func TestUserUpdate(t *testing.T) {
 			t.Run(test.name, func(t *testing.T) {
 				t.Parallel()
 
-				err := user.Update(model.NewID(), test.input)
+				err := user.Update(model.NewID(), model.AnyVersion, test.input)
 				require.ErrorAs(t, err, &core.InvalidError{})
 			})
 		}
@@ -591,7 +854,7 @@ func TestUserUpdate(t *testing.T) {
 
 		userid, _, _ := createTempUser(t, user, db, roles)
 		input := model.UserUpdate{Roles: []string{gofakeit.Name()}} //nolint:exhaustruct
-		err := user.Update(userid, input)
+		err := user.Update(userid, model.AnyVersion, input)
 		require.ErrorIs(t, err, errs.ErrRoleNotFound)
 	})
 
@@ -599,10 +862,72 @@ func TestUserUpdate(t *testing.T) {
 		t.Parallel()
 
 		input := model.UserUpdate{Name: gofakeit.Name()} //nolint:exhaustruct
-		err := user.Update(model.NewID(), input)
+		err := user.Update(model.NewID(), model.AnyVersion, input)
 		require.ErrorIs(t, err, errs.ErrUserNotFound)
 	})
 
+	t.Run("Version", func(t *testing.T) {
+		t.Parallel()
+
+		userid, _, _ := createTempUser(t, user, db, roles)
+
+		userdb, err := user.GetByID(userid)
+		require.NoError(t, err)
+
+		input := model.UserUpdate{Name: gofakeit.Name()} //nolint:exhaustruct
+
+		err = user.Update(userid, userdb.Version, input)
+		require.NoError(t, err)
+
+		err = user.Update(userid, userdb.Version, input)
+		require.ErrorIs(t, err, errs.ErrUserVersionMismatch)
+
+		updated, err := user.GetByID(userid)
+		require.NoError(t, err)
+		require.Equal(t, userdb.Version+1, updated.Version)
+
+		err = user.Update(userid, model.AnyVersion, input)
+		require.NoError(t, err)
+
+		err = user.Update(userid, updated.Version, input)
+		require.ErrorIs(t, err, errs.ErrUserVersionMismatch)
+	})
+
+	t.Run("AnyVersionConcurrent", func(t *testing.T) {
+		t.Parallel()
+
+		userid, _, _ := createTempUser(t, user, db, roles)
+
+		userdb, err := user.GetByID(userid)
+		require.NoError(t, err)
+
+		updates := 5
+		results := make([]error, updates)
+
+		var wait sync.WaitGroup
+
+		for i := range results {
+			wait.Add(1)
+
+			go func(i int) {
+				defer wait.Done()
+
+				input := model.UserUpdate{Name: gofakeit.Name()} //nolint:exhaustruct
+				results[i] = user.Update(userid, model.AnyVersion, input)
+			}(i)
+		}
+
+		wait.Wait()
+
+		for _, err := range results {
+			require.NoError(t, err)
+		}
+
+		updated, err := user.GetByID(userid)
+		require.NoError(t, err)
+		require.Equal(t, userdb.Version+updates, updated.Version)
+	})
+
 	t.Run("Duplicate", func(t *testing.T) {
 		t.Parallel()
 
@@ -611,19 +936,290 @@ func TestUserUpdate(t *testing.T) {
 
 		t.Run("Username", func(t *testing.T) {
 			input := model.UserUpdate{Username: userTemp2.Username} //nolint:exhaustruct
-			err := user.Update(id1, input)
+			err := user.Update(id1, model.AnyVersion, input)
 			require.ErrorIs(t, err, errs.ErrUsernameAlreadyExist)
 		})
 
 		t.Run("Email", func(t *testing.T) {
 			input := model.UserUpdate{Email: userTemp2.Email} //nolint:exhaustruct
-			err := user.Update(id1, input)
+			err := user.Update(id1, model.AnyVersion, input)
 			require.ErrorIs(t, err, errs.ErrEmailAlreadyExist)
 		})
 	})
 }
 
-func requireUserDelete(t *testing.T, partial partialUser, userdb model.User, user *core.User) {
+func TestUserUpdateSelf(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_update_self")
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+
+	qtRoles := 10
+	roles := make([]string, qtRoles)
+
+	for i := range roles {
+		_, role := createTempRole(t, role, db)
+		roles[i] = role.Name
+	}
+
+	t.Run("ValidInputs", func(t *testing.T) {
+		t.Parallel()
+
+		userid, _, userTemp := createTempUser(t, user, db, roles)
+
+		update := model.UserSelfUpdate{
+			Name:     gofakeit.Name(),
+			Username: gofakeit.Username(),
+			Email:    gofakeit.Email(),
+		}
+
+		err := user.UpdateSelf(userid, update)
+		require.NoError(t, err)
+
+		found, err := user.GetByID(userid)
+		require.NoError(t, err)
+		require.Equal(t, update.Name, found.Name)
+		require.Equal(t, update.Username, found.Username)
+		require.Equal(t, update.Email, found.Email)
+		require.Equal(t, userTemp.Roles, found.Roles)
+	})
+
+	t.Run("InvalidInputs", func(t *testing.T) {
+		t.Parallel()
+
+		userid, _, _ := createTempUser(t, user, db, roles)
+
+		err := user.UpdateSelf(userid, model.UserSelfUpdate{Email: "invalid-email"}) //nolint:exhaustruct
+		require.ErrorAs(t, err, &core.InvalidError{})
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		t.Parallel()
+
+		id1, _, _ := createTempUser(t, user, db, roles)
+		_, _, userTemp2 := createTempUser(t, user, db, roles)
+
+		input := model.UserSelfUpdate{Username: userTemp2.Username} //nolint:exhaustruct
+		err := user.UpdateSelf(id1, input)
+		require.ErrorIs(t, err, errs.ErrUsernameAlreadyExist)
+	})
+}
+
+func TestUserChangePassword(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_change_password")
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+
+	userid, _, userTemp := createTempUser(t, user, db, []string{})
+	newPassword := gofakeit.Password(true, true, true, true, true, 20)
+
+	err := user.ChangePassword(userid, model.PasswordUpdate{
+		CurrentPassword: newPassword,
+		NewPassword:     newPassword,
+	})
+	require.ErrorIs(t, err, errs.ErrPasswordDoesNotMatch)
+
+	err = user.ChangePassword(userid, model.PasswordUpdate{
+		CurrentPassword: userTemp.Password,
+		NewPassword:     "",
+	})
+	require.ErrorAs(t, err, &core.InvalidError{})
+
+	err = user.ChangePassword(model.NewID(), model.PasswordUpdate{
+		CurrentPassword: userTemp.Password,
+		NewPassword:     newPassword,
+	})
+	require.ErrorIs(t, err, errs.ErrUserNotFound)
+
+	err = user.ChangePassword(userid, model.PasswordUpdate{
+		CurrentPassword: userTemp.Password,
+		NewPassword:     newPassword,
+	})
+	require.NoError(t, err)
+
+	found, err := user.GetByID(userid)
+	require.NoError(t, err)
+
+	match, err := user.EqualPassword(newPassword, found.Password)
+	require.NoError(t, err)
+	require.True(t, match)
+}
+
+func TestUserPasswordHistory(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_password_history")
+
+	history := 3
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(
+		data.NewUserSQL(db),
+		role,
+		model.Validate(),
+		fastPasswordHash(),
+		nil,
+		history,
+		0,
+	)
+
+	t.Run("Current", func(t *testing.T) {
+		t.Parallel()
+
+		userid, _, userTemp := createTempUser(t, user, db, []string{})
+
+		input := model.UserUpdate{Password: userTemp.Password} //nolint:exhaustruct
+		err := user.Update(userid, model.AnyVersion, input)
+		require.ErrorIs(t, err, errs.ErrPasswordReused)
+		require.ErrorAs(t, err, &core.PasswordReusedError{})
+	})
+
+	t.Run("Previous", func(t *testing.T) {
+		t.Parallel()
+
+		userid, _, userTemp := createTempUser(t, user, db, []string{})
+
+		passwords := []string{userTemp.Password}
+
+		for i := 1; i < history; i++ {
+			password := gofakeit.Password(true, true, true, true, true, 20)
+
+			update := model.UserUpdate{Password: password} //nolint:exhaustruct
+			err := user.Update(userid, model.AnyVersion, update)
+			require.NoError(t, err)
+
+			passwords = append(passwords, password)
+		}
+
+		for _, password := range passwords {
+			update := model.UserUpdate{Password: password} //nolint:exhaustruct
+			err := user.Update(userid, model.AnyVersion, update)
+			require.ErrorIs(t, err, errs.ErrPasswordReused)
+		}
+
+		password := gofakeit.Password(true, true, true, true, true, 20)
+
+		update := model.UserUpdate{Password: password} //nolint:exhaustruct
+		err := user.Update(userid, model.AnyVersion, update)
+		require.NoError(t, err)
+
+		update = model.UserUpdate{Password: passwords[0]} //nolint:exhaustruct
+		err = user.Update(userid, model.AnyVersion, update)
+		require.NoError(t, err)
+	})
+}
+
+func TestUserImport(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_import")
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+
+	newImport := func() model.UserImport {
+		return model.UserImport{ //nolint:exhaustruct
+			Name:     gofakeit.Name(),
+			Username: gofakeit.Username(),
+			Email:    gofakeit.Email(),
+			Roles:    []string{},
+		}
+	}
+
+	t.Run("PasswordHash", func(t *testing.T) {
+		t.Parallel()
+
+		for _, foreign := range foreignHashes(t) {
+			input := newImport()
+			input.PasswordHash = foreign
+
+			userID, err := user.Import(model.NewID(), input)
+			require.NoError(t, err)
+
+			userdb, err := user.GetByID(userID)
+			require.NoError(t, err)
+			require.Equal(t, foreign, userdb.Password)
+			require.True(t, user.NeedsRehash(userdb.Password))
+
+			match, err := user.EqualPassword(foreignPassword, userdb.Password)
+			require.NoError(t, err)
+			require.True(t, match)
+		}
+	})
+
+	t.Run("Keycloak", func(t *testing.T) {
+		t.Parallel()
+
+		input := newImport()
+		input.Keycloak = &model.KeycloakCredential{
+			SecretData:     keycloakSecretSHA256,
+			CredentialData: keycloakCredentialSHA256,
+		}
+
+		userID, err := user.Import(model.NewID(), input)
+		require.NoError(t, err)
+
+		userdb, err := user.GetByID(userID)
+		require.NoError(t, err)
+
+		match, err := user.EqualPassword(foreignPassword, userdb.Password)
+		require.NoError(t, err)
+		require.True(t, match)
+	})
+
+	t.Run("InvalidInputs", func(t *testing.T) {
+		t.Parallel()
+
+		input := newImport()
+
+		userID, err := user.Import(model.NewID(), input)
+		require.ErrorAs(t, err, &core.InvalidError{})
+		require.Equal(t, model.EmptyID, userID)
+
+		input.PasswordHash = djangoPBKDF2SHA256
+		input.Keycloak = &model.KeycloakCredential{
+			SecretData:     keycloakSecretSHA256,
+			CredentialData: keycloakCredentialSHA256,
+		}
+
+		userID, err = user.Import(model.NewID(), input)
+		require.ErrorAs(t, err, &core.InvalidError{})
+		require.Equal(t, model.EmptyID, userID)
+	})
+
+	t.Run("UnknownHash", func(t *testing.T) {
+		t.Parallel()
+
+		input := newImport()
+		input.PasswordHash = gofakeit.LetterN(50)
+
+		userID, err := user.Import(model.NewID(), input)
+		require.ErrorIs(t, err, errs.ErrHashUnknown)
+		require.Equal(t, model.EmptyID, userID)
+	})
+
+	t.Run("HashAboveLimits", func(t *testing.T) {
+		t.Parallel()
+
+		for _, expensive := range expensiveHashes(t) {
+			input := newImport()
+			input.PasswordHash = expensive
+
+			userID, err := user.Import(model.NewID(), input)
+			require.ErrorIs(t, err, errs.ErrHashParameters, expensive)
+			require.Equal(t, model.EmptyID, userID)
+		}
+	})
+}
+
+// requireUserDeleteListed checks deleted users returned by list queries, which
+// never load the password hash.
+func requireUserDeleteListed(t *testing.T, partial partialUser, userdb model.User) {
 	t.Helper()
 
 	require.Equal(t, partial.userID, userdb.ID)
@@ -636,10 +1232,7 @@ func requireUserDelete(t *testing.T, partial partialUser, userdb model.User, use
 	require.Equal(t, partial.createdBy, userdb.CreatedBy)
 	require.LessOrEqual(t, time.Since(userdb.DeletedAt), time.Minute)
 	require.Equal(t, partial.deletedBy, userdb.DeletedBy)
-
-	match, err := user.EqualPassword(partial.input.Password, userdb.Password)
-	require.NoError(t, err)
-	require.True(t, match)
+	require.Empty(t, userdb.Password)
 }
 
 func TestUserDelete(t *testing.T) { //nolint:funlen
@@ -648,7 +1241,7 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 	db := createTempDB(t, "user_get")
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), false)
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
 
 	qtRoles := 10
 	roles := make([]string, qtRoles)
@@ -732,10 +1325,18 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 		})
 	}
 
+	t.Run("GetAll/ExcludeDeleted", func(t *testing.T) {
+		t.Parallel()
+
+		usersdb, err := items(user.GetAll(model.ExcludeDeleted, model.FirstPage(qtUsers)))
+		require.NoError(t, err)
+		require.Equal(t, model.EmptyUsers, usersdb)
+	})
+
 	t.Run("GetAll", func(t *testing.T) {
 		t.Parallel()
 
-		usersdb, err := user.GetAll(0, qtUsers)
+		usersdb, err := items(user.GetAll(model.OnlyDeleted, model.FirstPage(qtUsers)))
 		require.NoError(t, err)
 
 		require.Equal(t, qtUsers, len(usersdb))
@@ -745,7 +1346,7 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 				return p.userID == userdb.ID
 			})
 
-			requireUserDelete(t, usersTemp[index], userdb, user)
+			requireUserDeleteListed(t, usersTemp[index], userdb)
 		}
 	})
 
@@ -756,7 +1357,7 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 		t.Run("GetByRole", func(t *testing.T) {
 			t.Parallel()
 
-			usersdb, err := user.GetByRole([]string{role}, 0, qtUsers)
+			usersdb, err := items(user.GetByRole([]string{role}, model.OnlyDeleted, model.FirstPage(qtUsers)))
 			require.NoError(t, err)
 
 			require.Equal(t, sum, len(usersdb))
@@ -765,7 +1366,7 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 				index := slices.IndexFunc(usersTemp, func(p partialUser) bool {
 					return p.userID == userdb.ID
 				})
-				requireUserDelete(t, usersTemp[index], userdb, user)
+				requireUserDeleteListed(t, usersTemp[index], userdb)
 			}
 		})
 	}
@@ -773,7 +1374,7 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 	t.Run("GetByRole/NotExist", func(t *testing.T) {
 		t.Parallel()
 
-		usersdb, err := user.GetByRole([]string{gofakeit.Name()}, 0, qtRoles)
+		usersdb, err := items(user.GetByRole([]string{gofakeit.Name()}, model.OnlyDeleted, model.FirstPage(qtRoles)))
 		require.ErrorIs(t, err, errs.ErrRoleNotFound)
 		require.Equal(t, model.EmptyUsers, usersdb)
 	})
@@ -793,7 +1394,7 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 					sum := sum
 
 					t.Run(role2, func(t *testing.T) {
-						usersdb, err := user.GetByRole([]string{role1, role2}, 0, qtUsers)
+						usersdb, err := items(user.GetByRole([]string{role1, role2}, model.OnlyDeleted, model.FirstPage(qtUsers)))
 						require.NoError(t, err)
 
 						require.Equal(t, sum, len(usersdb))
@@ -802,7 +1403,7 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 							index := slices.IndexFunc(usersTemp, func(p partialUser) bool {
 								return p.userID == userdb.ID
 							})
-							requireUserDelete(t, usersTemp[index], userdb, user)
+							requireUserDeleteListed(t, usersTemp[index], userdb)
 						}
 					})
 				}
@@ -818,13 +1419,84 @@ func TestUserDelete(t *testing.T) { //nolint:funlen
 	})
 }
 
+func TestUserRestore(t *testing.T) {
+	t.Parallel()
+
+	db := createTempDB(t, "user_restore")
+
+	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
+	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), fastPasswordHash(), nil, 0, 0)
+
+	qtRoles := 10
+	roles := make([]string, qtRoles)
+
+	for i := range roles {
+		_, role := createTempRole(t, role, db)
+		roles[i] = role.Name
+	}
+
+	userID, createdBy, userTemp := createTempUser(t, user, db, roles)
+
+	err := user.Restore(model.NewID(), userID)
+	require.ErrorIs(t, err, errs.ErrUserNotDeleted)
+
+	err = user.Delete(userID, model.NewID())
+	require.NoError(t, err)
+
+	err = user.Restore(model.NewID(), userID)
+	require.NoError(t, err)
+
+	userdb, err := user.GetByID(userID)
+	require.NoError(t, err)
+
+	partial := partialUser{
+		userID:    userID,
+		input:     userTemp,
+		createdBy: createdBy,
+		deletedBy: model.EmptyID,
+	}
+	requireUser(t, partial, userdb, user)
+
+	usersdb, err := items(user.GetAll(model.ExcludeDeleted, model.FirstPage(10)))
+	require.NoError(t, err)
+	require.Len(t, usersdb, 1)
+
+	err = user.Restore(model.NewID(), model.NewID())
+	require.ErrorIs(t, err, errs.ErrUserNotFound)
+
+	t.Run("Reused", func(t *testing.T) {
+		t.Parallel()
+
+		deletedID, _, input := createTempUser(t, user, db, roles)
+
+		err := user.Delete(deletedID, model.NewID())
+		require.NoError(t, err)
+
+		input.Email = gofakeit.Email()
+
+		_, err = user.Create(model.NewID(), input)
+		require.NoError(t, err)
+
+		err = user.Restore(model.NewID(), deletedID)
+		require.ErrorIs(t, err, errs.ErrUsernameAlreadyExist)
+	})
+}
+
 func TestUserWithArgon(t *testing.T) {
 	t.Parallel()
 
 	db := createTempDB(t, "user_get_argon")
 
 	role := core.NewRole(data.NewRoleSQL(db), model.Validate())
-	user := core.NewUser(data.NewUserSQL(db), role, model.Validate(), true)
+	user := core.NewUser(
+		data.NewUserSQL(db),
+		role,
+		model.Validate(),
+		core.DefaultPasswordHash(),
+		nil,
+		0,
+		0,
+	)
 
 	qtRoles := 5
 	roles := make([]string, qtRoles)
@@ -855,7 +1527,7 @@ func TestUserWithArgon(t *testing.T) {
 			update := model.UserUpdate{ //nolint:exhaustruct
 				Password: gofakeit.Password(true, true, true, true, true, 20),
 			}
-			err = user.Update(userid, update)
+			err = user.Update(userid, model.AnyVersion, update)
 			require.NoError(t, err)
 			requireUserUpdate(t, update, userTemp, userid, user)
 
@@ -882,11 +1554,11 @@ func checkUserWrongDB(
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, model.EmptyID, id)
 
-	users, err := user.GetAll(0, 100)
+	users, err := items(user.GetAll(model.ExcludeDeleted, model.FirstPage(100)))
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, model.EmptyUsers, users)
 
-	users, err = user.GetByRole(rolesValid[0:2], 0, 100)
+	users, err = items(user.GetByRole(rolesValid[0:2], model.ExcludeDeleted, model.FirstPage(100)))
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, model.EmptyUsers, users)
 
@@ -902,7 +1574,7 @@ func checkUserWrongDB(
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, model.EmptyUser, roleTemp)
 
-	err = user.Update(model.NewID(), update)
+	err = user.Update(model.NewID(), model.AnyVersion, update)
 	require.ErrorContains(t, err, "no such host")
 
 	err = user.Delete(model.NewID(), model.NewID())
@@ -930,8 +1602,24 @@ func TestUserWrongDB(t *testing.T) {
 		rolesValid[i] = role.Name
 	}
 
-	user1 := core.NewUser(data.NewUserSQL(db), role1, model.Validate(), true)
-	user2 := core.NewUser(data.NewUserSQL(db), role2, model.Validate(), true)
+	user1 := core.NewUser(
+		data.NewUserSQL(db),
+		role1,
+		model.Validate(),
+		core.DefaultPasswordHash(),
+		nil,
+		0,
+		0,
+	)
+	user2 := core.NewUser(
+		data.NewUserSQL(db),
+		role2,
+		model.Validate(),
+		core.DefaultPasswordHash(),
+		nil,
+		0,
+		0,
+	)
 
 	input := model.UserPartial{
 		Name:     gofakeit.Name(),
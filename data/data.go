@@ -10,37 +10,73 @@ import (
 
 type Role interface {
 	GetByName(name string) (model.Role, error)
-	GetAll(paginate int, qt int) ([]model.Role, error)
+	GetAll(deleted model.Deleted, pagination model.Pagination) (model.Page[model.Role], error)
+	GetByNames(names []string) ([]model.Role, error)
 	Exist(roles []string) (bool, error)
 	Create(role model.Role) error
 	Delete(name string, deletedAt time.Time, deletedBy model.ID) error
+	Restore(name string) error
 }
 
 type User interface {
 	GetByID(id model.ID) (model.User, error)
 	GetByUsername(username string) (model.User, error)
 	GetByEmail(email string) (model.User, error)
-	GetAll(paginate int, qt int) ([]model.User, error)
-	GetByRoles(role []string, paginate int, qt int) ([]model.User, error)
+	GetAll(deleted model.Deleted, pagination model.Pagination) (model.Page[model.User], error)
+	Search(filter model.UserFilter, pagination model.Pagination) (model.Page[model.User], error)
+	GetByRoles(
+		role []string,
+		deleted model.Deleted,
+		pagination model.Pagination,
+	) (model.Page[model.User], error)
+	GetSummaries(ids []model.ID) ([]model.UserSummary, error)
+	GetPasswords(id model.ID, qt int) ([]string, error)
 	Create(user model.User) error
 	Update(user model.User) error
+	UpdatePassword(id model.ID, password string) error
 	Delete(id model.ID, deletedAt time.Time, deletedBy model.ID) error
+	Restore(id model.ID) error
 }
 
 type UserSession interface {
 	GetByID(id model.ID) (model.UserSession, error)
-	GetAllActive(paginate int, qt int) ([]model.UserSession, error)
-	GetByUserIDActive(id model.ID, paginate int, qt int) ([]model.UserSession, error)
-	GetAllInactive(paginate int, qt int) ([]model.UserSession, error)
-	GetByUserIDInactive(id model.ID, paginate int, qt int) ([]model.UserSession, error)
+	GetAllActive(pagination model.Pagination) (model.Page[model.UserSession], error)
+	GetByUserIDActive(
+		id model.ID,
+		pagination model.Pagination,
+	) (model.Page[model.UserSession], error)
+	GetAllInactive(pagination model.Pagination) (model.Page[model.UserSession], error)
+	GetByUserIDInactive(
+		id model.ID,
+		pagination model.Pagination,
+	) (model.Page[model.UserSession], error)
 	Create(user model.UserSession) error
+	CreateWithLimit(user model.UserSession, limit int, evict bool) ([]model.UserSession, error)
 	Delete(id model.ID, deletetAd time.Time) (model.UserSession, error)
+	DeleteByUserID(userID model.ID, deletedAt time.Time) ([]model.UserSession, error)
+	Rotate(
+		id model.ID,
+		next model.UserSession,
+		deletedAt time.Time,
+		grace time.Duration,
+	) (model.UserSession, error)
+	GetSuccessor(id model.ID) (model.UserSession, error)
+}
+
+type Token interface {
+	GetByID(id model.ID) (model.Token, error)
+	GetByHash(hash string) (model.Token, error)
+	GetByUserID(userID model.ID, pagination model.Pagination) (model.Page[model.Token], error)
+	Create(token model.Token) error
+	Delete(id model.ID, deletedAt time.Time, deletedBy model.ID) error
+	DeleteByUserID(userID model.ID, deletedAt time.Time, deletedBy model.ID) error
 }
 
 type Data struct {
 	Role
 	User
 	UserSession
+	Token
 }
 
 func NewDataSQLRedis(
@@ -53,6 +89,7 @@ func NewDataSQLRedis(
 	role := NewRoleSQL(db)
 	user := NewUserSQL(db)
 	userSession := NewUserSessionRedis(redis, db, bufferSize)
+	token := NewTokenSQL(db)
 
 	err := userSession.ConsumeQueues(expires, queueSize)
 	userSession.LogErrors()
@@ -61,5 +98,6 @@ func NewDataSQLRedis(
 		Role:        role,
 		User:        user,
 		UserSession: userSession,
+		Token:       token,
 	}, err
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 	"github.com/thiago-felipe-99/autenticacao/data"
+	"github.com/thiago-felipe-99/autenticacao/model"
 )
 
 func createTempDB(t *testing.T, name string) *sqlx.DB {
@@ -63,6 +64,11 @@ func createTempDB(t *testing.T, name string) *sqlx.DB {
 	return db
 }
 
+// items returns the items of a page, for tests that only check the items.
+func items[T any](page model.Page[T], err error) ([]T, error) {
+	return page.Items, err
+}
+
 func createWrongDB(t *testing.T) *sqlx.DB {
 	t.Helper()
 
@@ -0,0 +1,252 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+// likeEscaper escapes the LIKE wildcards, so a filter only matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`) //nolint: gochecknoglobals
+
+// sortColumn is a column of the order of a list. The last column of a list
+// must be unique, so the order is total and the pages are stable.
+type sortColumn struct {
+	column     string
+	descending bool
+}
+
+func (s sortColumn) String() string {
+	if s.descending {
+		return s.column + " DESC"
+	}
+
+	return s.column
+}
+
+func (s sortColumn) operator() string {
+	if s.descending {
+		return " < "
+	}
+
+	return " > "
+}
+
+func cursorTime(value time.Time) string {
+	return value.Format(time.RFC3339Nano)
+}
+
+// encodeCursor makes an opaque cursor from the values of the sort columns of
+// a row.
+func encodeCursor(key []string) string {
+	serial, _ := json.Marshal(key) //nolint:errchkjson
+
+	return base64.RawURLEncoding.EncodeToString(serial)
+}
+
+func decodeCursor(cursor string, size int) ([]string, error) {
+	serial, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	key := []string{}
+
+	err = json.Unmarshal(serial, &key)
+	if err != nil || len(key) != size {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	return key, nil
+}
+
+// listQuery builds the queries of a list. Values are always passed as
+// positional arguments, only fixed SQL fragments are added to the query text.
+type listQuery struct {
+	selection  string
+	conditions []string
+	orders     []sortColumn
+	args       []any
+}
+
+func newListQuery(selection string, orders ...sortColumn) *listQuery {
+	return &listQuery{
+		selection:  selection,
+		conditions: []string{},
+		orders:     orders,
+		args:       []any{},
+	}
+}
+
+func (q *listQuery) arg(value any) string {
+	q.args = append(q.args, value)
+
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *listQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+func (q *listQuery) contains(column string, value string) {
+	if value != "" {
+		q.where(column + " ILIKE '%' || " + q.arg(likeEscaper.Replace(value)) + " || '%'")
+	}
+}
+
+// deleted keeps the rows by their soft delete column.
+func (q *listQuery) deleted(column string, deleted model.Deleted) {
+	switch deleted {
+	case model.ExcludeDeleted:
+		q.where(column + " = " + q.arg(time.Time{}))
+	case model.OnlyDeleted:
+		q.where(column + " <> " + q.arg(time.Time{}))
+	case model.IncludeDeleted:
+	}
+}
+
+// after keeps the rows after the key in the order of the list. When all the
+// columns have the same direction it is a row comparison, which can use the
+// index of the order.
+func (q *listQuery) after(key []string) {
+	sameDirection := true
+	for _, order := range q.orders {
+		sameDirection = sameDirection && order.descending == q.orders[0].descending
+	}
+
+	if sameDirection {
+		columns := make([]string, 0, len(q.orders))
+		values := make([]string, 0, len(q.orders))
+
+		for i, order := range q.orders {
+			columns = append(columns, order.column)
+			values = append(values, q.arg(key[i]))
+		}
+
+		q.where("(" + strings.Join(columns, ", ") + ")" + q.orders[0].operator() +
+			"(" + strings.Join(values, ", ") + ")")
+
+		return
+	}
+
+	alternatives := make([]string, 0, len(q.orders))
+
+	for i, order := range q.orders {
+		parts := make([]string, 0, i+1)
+
+		for j := 0; j < i; j++ {
+			parts = append(parts, q.orders[j].column+" = "+q.arg(key[j]))
+		}
+
+		parts = append(parts, order.column+order.operator()+q.arg(key[i]))
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	q.where("(" + strings.Join(alternatives, " OR ") + ")")
+}
+
+func (q *listQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+
+	return "\n\t\tWHERE " + strings.Join(q.conditions, " AND ")
+}
+
+func (q *listQuery) count() string {
+	return "SELECT count(*) FROM (" + q.selection + q.whereClause() + ") AS list"
+}
+
+func (q *listQuery) build(qt int) string {
+	orders := make([]string, 0, len(q.orders))
+	for _, order := range q.orders {
+		orders = append(orders, order.String())
+	}
+
+	return q.selection + q.whereClause() +
+		"\n\t\tORDER BY " + strings.Join(orders, ", ") +
+		"\n\t\tLIMIT " + q.arg(qt)
+}
+
+// selectPage selects a page of the list after the cursor. It reads one row
+// more than the page to know if there is a next page, whose cursor is the key
+// of the last row of the page.
+func selectPage[T any](
+	database *sqlx.DB,
+	query *listQuery,
+	pagination model.Pagination,
+	key func(T) []string,
+) (model.Page[T], error) {
+	page := model.EmptyPage[T]()
+
+	var cursor []string
+
+	if pagination.Cursor != "" {
+		var err error
+
+		cursor, err = decodeCursor(pagination.Cursor, len(query.orders))
+		if err != nil {
+			return model.EmptyPage[T](), err
+		}
+	}
+
+	if pagination.Total {
+		total := 0
+
+		err := database.Get(&total, query.count(), query.args...)
+		if err != nil {
+			return model.EmptyPage[T](), fmt.Errorf("error counting list: %w", err)
+		}
+
+		page.Total = &total
+	}
+
+	if cursor != nil {
+		query.after(cursor)
+	}
+
+	qt := max(pagination.Qt, 0)
+	rows := make([]T, 0, qt+1)
+
+	err := database.Select(&rows, query.build(qt+1), query.args...)
+	if err != nil {
+		// the cursor values are only checked by the database
+		var pqErr *pq.Error
+		if cursor != nil && errors.As(err, &pqErr) && pqErr.Code.Class() == "22" {
+			return model.EmptyPage[T](), errs.ErrInvalidCursor
+		}
+
+		return model.EmptyPage[T](), fmt.Errorf("error selecting list: %w", err)
+	}
+
+	if len(rows) > qt {
+		rows = rows[:qt]
+
+		if qt > 0 {
+			page.NextCursor = encodeCursor(key(rows[qt-1]))
+		}
+	}
+
+	page.Items = rows
+
+	return page, nil
+}
+
+// mapPage converts the items of a page.
+func mapPage[T any, R any](page model.Page[T], convert func(T) R) model.Page[R] {
+	items := make([]R, 0, len(page.Items))
+	for _, item := range page.Items {
+		items = append(items, convert(item))
+	}
+
+	return model.Page[R]{Items: items, NextCursor: page.NextCursor, Total: page.Total}
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS
+  users_passwords (
+    userid uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password VARCHAR(255) NOT NULL,
+    replaced_at timestamp with time zone NOT NULL
+  );
+
+CREATE INDEX IF NOT EXISTS users_passwords_userid_replaced_at_idx ON users_passwords (userid, replaced_at DESC);
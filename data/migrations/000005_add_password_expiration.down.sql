@@ -0,0 +1,9 @@
+ALTER TABLE users_sessions_deleted
+DROP COLUMN IF EXISTS restricted;
+
+ALTER TABLE users_sessions_created
+DROP COLUMN IF EXISTS restricted;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS must_change_password,
+DROP COLUMN IF EXISTS password_changed_at;
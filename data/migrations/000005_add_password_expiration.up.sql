@@ -0,0 +1,9 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS password_changed_at timestamp with time zone NOT NULL DEFAULT now(),
+ADD COLUMN IF NOT EXISTS must_change_password boolean NOT NULL DEFAULT false;
+
+ALTER TABLE users_sessions_created
+ADD COLUMN IF NOT EXISTS restricted boolean NOT NULL DEFAULT false;
+
+ALTER TABLE users_sessions_deleted
+ADD COLUMN IF NOT EXISTS restricted boolean NOT NULL DEFAULT false;
@@ -0,0 +1,11 @@
+ALTER TABLE users_sessions_deleted
+DROP COLUMN IF EXISTS device_name,
+DROP COLUMN IF EXISTS device,
+DROP COLUMN IF EXISTS user_agent,
+DROP COLUMN IF EXISTS ip;
+
+ALTER TABLE users_sessions_created
+DROP COLUMN IF EXISTS device_name,
+DROP COLUMN IF EXISTS device,
+DROP COLUMN IF EXISTS user_agent,
+DROP COLUMN IF EXISTS ip;
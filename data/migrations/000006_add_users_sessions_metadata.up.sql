@@ -0,0 +1,11 @@
+ALTER TABLE users_sessions_created
+ADD COLUMN IF NOT EXISTS ip VARCHAR(45) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS device VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS device_name VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE users_sessions_deleted
+ADD COLUMN IF NOT EXISTS ip VARCHAR(45) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS device VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS device_name VARCHAR(255) NOT NULL DEFAULT '';
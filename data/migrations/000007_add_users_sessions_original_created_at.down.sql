@@ -0,0 +1,5 @@
+ALTER TABLE users_sessions_deleted
+DROP COLUMN IF EXISTS original_created_at;
+
+ALTER TABLE users_sessions_created
+DROP COLUMN IF EXISTS original_created_at;
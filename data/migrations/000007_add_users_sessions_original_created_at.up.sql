@@ -0,0 +1,19 @@
+ALTER TABLE users_sessions_created
+ADD COLUMN IF NOT EXISTS original_created_at timestamp with time zone;
+
+UPDATE users_sessions_created
+SET original_created_at = created_at
+WHERE original_created_at IS NULL;
+
+ALTER TABLE users_sessions_created
+ALTER COLUMN original_created_at SET NOT NULL;
+
+ALTER TABLE users_sessions_deleted
+ADD COLUMN IF NOT EXISTS original_created_at timestamp with time zone;
+
+UPDATE users_sessions_deleted
+SET original_created_at = created_at
+WHERE original_created_at IS NULL;
+
+ALTER TABLE users_sessions_deleted
+ALTER COLUMN original_created_at SET NOT NULL;
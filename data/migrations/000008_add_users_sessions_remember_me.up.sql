@@ -0,0 +1,5 @@
+ALTER TABLE users_sessions_created
+ADD COLUMN IF NOT EXISTS remember_me boolean NOT NULL DEFAULT false;
+
+ALTER TABLE users_sessions_deleted
+ADD COLUMN IF NOT EXISTS remember_me boolean NOT NULL DEFAULT false;
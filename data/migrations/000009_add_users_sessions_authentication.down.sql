@@ -0,0 +1,7 @@
+ALTER TABLE users_sessions_deleted
+DROP COLUMN IF EXISTS authentication_method,
+DROP COLUMN IF EXISTS authenticated_at;
+
+ALTER TABLE users_sessions_created
+DROP COLUMN IF EXISTS authentication_method,
+DROP COLUMN IF EXISTS authenticated_at;
@@ -0,0 +1,21 @@
+ALTER TABLE users_sessions_created
+ADD COLUMN IF NOT EXISTS authenticated_at timestamp with time zone,
+ADD COLUMN IF NOT EXISTS authentication_method VARCHAR(32) NOT NULL DEFAULT 'password';
+
+UPDATE users_sessions_created
+SET authenticated_at = created_at
+WHERE authenticated_at IS NULL;
+
+ALTER TABLE users_sessions_created
+ALTER COLUMN authenticated_at SET NOT NULL;
+
+ALTER TABLE users_sessions_deleted
+ADD COLUMN IF NOT EXISTS authenticated_at timestamp with time zone,
+ADD COLUMN IF NOT EXISTS authentication_method VARCHAR(32) NOT NULL DEFAULT 'password';
+
+UPDATE users_sessions_deleted
+SET authenticated_at = created_at
+WHERE authenticated_at IS NULL;
+
+ALTER TABLE users_sessions_deleted
+ALTER COLUMN authenticated_at SET NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE users_sessions_deleted
+DROP COLUMN IF EXISTS impersonator_id;
+
+ALTER TABLE users_sessions_created
+DROP COLUMN IF EXISTS impersonator_id;
@@ -0,0 +1,5 @@
+ALTER TABLE users_sessions_created
+ADD COLUMN IF NOT EXISTS impersonator_id uuid NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+
+ALTER TABLE users_sessions_deleted
+ADD COLUMN IF NOT EXISTS impersonator_id uuid NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
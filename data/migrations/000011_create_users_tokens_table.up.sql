@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS
+  users_tokens (
+    id uuid NOT NULL,
+    userid uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    hash VARCHAR(64) UNIQUE NOT NULL,
+    roles VARCHAR(255) [] NOT NULL,
+    expires timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    created_by uuid NOT NULL,
+    deleted_at timestamp with time zone NOT NULL,
+    deleted_by uuid NOT NULL,
+    PRIMARY KEY (id)
+  );
+
+CREATE INDEX IF NOT EXISTS users_tokens_userid_idx ON users_tokens (userid);
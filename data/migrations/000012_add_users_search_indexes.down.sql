@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS users_created_by_idx;
+
+DROP INDEX IF EXISTS users_created_at_idx;
+
+DROP INDEX IF EXISTS users_roles_idx;
+
+DROP INDEX IF EXISTS users_email_trgm_idx;
+
+DROP INDEX IF EXISTS users_username_trgm_idx;
+
+DROP INDEX IF EXISTS users_name_trgm_idx;
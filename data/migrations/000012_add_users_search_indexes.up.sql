@@ -0,0 +1,13 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS users_name_trgm_idx ON users USING gin (name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS users_username_trgm_idx ON users USING gin (username gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS users_email_trgm_idx ON users USING gin (email gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS users_roles_idx ON users USING gin (roles);
+
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON users (created_at, id);
+
+CREATE INDEX IF NOT EXISTS users_created_by_idx ON users (created_by);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS users_email_not_deleted_idx;
+
+DROP INDEX IF EXISTS users_username_not_deleted_idx;
+
+ALTER TABLE users
+ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE users
+ADD CONSTRAINT users_username_key UNIQUE (username);
@@ -0,0 +1,13 @@
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_username_key;
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_email_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_not_deleted_idx ON users (username)
+WHERE
+  deleted_at = '0001-01-01 00:00:00+00';
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_not_deleted_idx ON users (email)
+WHERE
+  deleted_at = '0001-01-01 00:00:00+00';
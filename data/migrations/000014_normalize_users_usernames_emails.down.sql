@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS users_email_lower_not_deleted_idx;
+
+DROP INDEX IF EXISTS users_username_lower_not_deleted_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_not_deleted_idx ON users (username)
+WHERE
+  deleted_at = '0001-01-01 00:00:00+00';
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_not_deleted_idx ON users (email)
+WHERE
+  deleted_at = '0001-01-01 00:00:00+00';
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS users_username_not_deleted_idx;
+
+DROP INDEX IF EXISTS users_email_not_deleted_idx;
+
+UPDATE users
+SET
+  username = normalize(username, NFKC),
+  email = lower(email);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_not_deleted_idx ON users (lower(username))
+WHERE
+  deleted_at = '0001-01-01 00:00:00+00';
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_not_deleted_idx ON users (lower(email))
+WHERE
+  deleted_at = '0001-01-01 00:00:00+00';
@@ -38,20 +38,40 @@ func (r *RoleSQL) GetByName(name string) (model.Role, error) {
 	return role, nil
 }
 
-func (r *RoleSQL) GetAll(paginate int, qt int) ([]model.Role, error) {
-	roles := make([]model.Role, 0, qt)
+func (r *RoleSQL) GetAll(
+	deleted model.Deleted,
+	pagination model.Pagination,
+) (model.Page[model.Role], error) {
+	query := newListQuery(
+		`SELECT name, created_at, created_by, deleted_at, deleted_by 
+		FROM role`,
+		sortColumn{"name", false},
+	)
+	query.deleted("deleted_at", deleted)
+
+	key := func(role model.Role) []string { return []string{role.Name} }
+
+	roles, err := selectPage(r.database, query, pagination, key)
+	if err != nil {
+		return model.EmptyPage[model.Role](), fmt.Errorf("error get roles in database: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *RoleSQL) GetByNames(names []string) ([]model.Role, error) {
+	roles := make([]model.Role, 0, len(names))
 
 	err := r.database.Select(
 		&roles,
 		`SELECT name, created_at, created_by, deleted_at, deleted_by 
 		FROM role 
-		LIMIT $1 
-		OFFSET $2`,
-		qt,
-		qt*paginate,
+		WHERE deleted_at = $1 AND name = ANY($2)`,
+		time.Time{},
+		pq.StringArray(names),
 	)
 	if err != nil {
-		return model.EmptyRoles, fmt.Errorf("error get roles in database: %w", err)
+		return model.EmptyRoles, fmt.Errorf("error get roles by names in database: %w", err)
 	}
 
 	return roles, nil
@@ -75,6 +95,12 @@ func (r *RoleSQL) Exist(roles []string) (bool, error) {
 	return count == len(roles), nil
 }
 
+// roleConstraints are the unique constraints of the roles, deleted roles keep
+// their names, so they can be restored.
+var roleConstraints = map[string]error{ //nolint: gochecknoglobals
+	"role_pkey": errs.ErrRoleAlreadyExist,
+}
+
 func (r *RoleSQL) Create(role model.Role) error {
 	_, err := r.database.NamedExec(
 		`INSERT INTO role (name, created_at, created_by, deleted_at, deleted_by)
@@ -82,7 +108,7 @@ func (r *RoleSQL) Create(role model.Role) error {
 		role,
 	)
 	if err != nil {
-		return fmt.Errorf("error inserting role: %w", err)
+		return fmt.Errorf("error inserting role: %w", uniqueViolation(err, roleConstraints))
 	}
 
 	return nil
@@ -126,6 +152,31 @@ func (r *RoleSQL) Delete(name string, deletedAt time.Time, deletedBy model.ID) (
 	return nil
 }
 
+// Restore undoes the soft delete of the role, returning errs.ErrRoleNotFound
+// when there is no deleted role with the name.
+func (r *RoleSQL) Restore(name string) error {
+	result, err := r.database.Exec(
+		"UPDATE role SET deleted_at=$1, deleted_by=$2 WHERE name=$3 AND deleted_at <> $1",
+		time.Time{},
+		model.EmptyID,
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("error restoring role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting restored roles: %w", err)
+	}
+
+	if rows == 0 {
+		return errs.ErrRoleNotFound
+	}
+
+	return nil
+}
+
 var _ Role = &RoleSQL{} //nolint: exhaustruct
 
 func NewRoleSQL(db *sqlx.DB) *RoleSQL {
@@ -37,6 +37,24 @@ func TestRoleCreate(t *testing.T) {
 			require.NoError(t, err)
 		})
 	}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		t.Parallel()
+
+		tempRole := createRole()
+
+		err := role.Create(tempRole)
+		require.NoError(t, err)
+
+		err = role.Create(tempRole)
+		require.ErrorIs(t, err, errs.ErrRoleAlreadyExist)
+
+		err = role.Delete(tempRole.Name, time.Now(), model.NewID())
+		require.NoError(t, err)
+
+		err = role.Create(tempRole)
+		require.ErrorIs(t, err, errs.ErrRoleAlreadyExist)
+	})
 }
 
 func checkRole(t *testing.T, expected, found model.Role) {
@@ -159,6 +177,50 @@ func TestRoleExist(t *testing.T) {
 	})
 }
 
+func TestRoleGetByNames(t *testing.T) {
+	t.Parallel()
+
+	qtRoles := 10
+
+	role := data.NewRoleSQL(createTempDB(t, "data_role_get_by_names"))
+
+	createdRoles := make([]model.Role, 0, qtRoles)
+	names := make([]string, 0, qtRoles+1)
+
+	for i := 0; i < qtRoles; i++ {
+		tempRole := createRole()
+
+		err := role.Create(tempRole)
+		require.NoError(t, err)
+
+		createdRoles = append(createdRoles, tempRole)
+		names = append(names, tempRole.Name)
+	}
+
+	found, err := role.GetByNames(append(names, "invalid-role"))
+	require.NoError(t, err)
+	require.Len(t, found, qtRoles)
+
+	for _, createdRole := range createdRoles {
+		index := slices.IndexFunc(found, func(role model.Role) bool {
+			return role.Name == createdRole.Name
+		})
+		require.GreaterOrEqual(t, index, 0)
+		checkRole(t, createdRole, found[index])
+	}
+
+	err = role.Delete(names[0], time.Now(), model.NewID())
+	require.NoError(t, err)
+
+	found, err = role.GetByNames(names)
+	require.NoError(t, err)
+	require.Len(t, found, qtRoles-1)
+
+	found, err = role.GetByNames([]string{})
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
+
 func TestRoleGetAll(t *testing.T) { //nolint:dupl
 	t.Parallel()
 
@@ -167,7 +229,7 @@ func TestRoleGetAll(t *testing.T) { //nolint:dupl
 
 	role := data.NewRoleSQL(createTempDB(t, "data_role_get_all"))
 
-	roles, err := role.GetAll(0, qtRoles)
+	roles, err := items(role.GetAll(model.ExcludeDeleted, model.FirstPage(qtRoles)))
 	require.NoError(t, err)
 	require.Equal(t, roles, model.EmptyRoles)
 
@@ -179,7 +241,7 @@ func TestRoleGetAll(t *testing.T) { //nolint:dupl
 		require.NoError(t, err)
 	}
 
-	roles, err = role.GetAll(0, qtRoles)
+	roles, err = items(role.GetAll(model.ExcludeDeleted, model.FirstPage(qtRoles)))
 	require.NoError(t, err)
 	require.Equal(t, len(roles), qtRoles)
 
@@ -196,7 +258,15 @@ func TestRoleGetAll(t *testing.T) { //nolint:dupl
 		require.NoError(t, err)
 	}
 
-	roles, err = role.GetAll(0, qtRoles)
+	roles, err = items(role.GetAll(model.ExcludeDeleted, model.FirstPage(qtRoles)))
+	require.NoError(t, err)
+	require.Equal(t, model.EmptyRoles, roles)
+
+	roles, err = items(role.GetAll(model.OnlyDeleted, model.FirstPage(qtRoles)))
+	require.NoError(t, err)
+	require.Equal(t, len(roles), qtRoles)
+
+	roles, err = items(role.GetAll(model.IncludeDeleted, model.FirstPage(qtRoles)))
 	require.NoError(t, err)
 	require.Equal(t, len(roles), qtRoles)
 
@@ -278,6 +348,36 @@ func TestRoleDelete(t *testing.T) {
 	})
 }
 
+func TestRoleRestore(t *testing.T) {
+	t.Parallel()
+
+	role := data.NewRoleSQL(createTempDB(t, "data_role_restore"))
+
+	tempRole := createRole()
+
+	err := role.Create(tempRole)
+	require.NoError(t, err)
+
+	err = role.Restore(tempRole.Name)
+	require.ErrorIs(t, err, errs.ErrRoleNotFound)
+
+	err = role.Delete(tempRole.Name, time.Now(), model.NewID())
+	require.NoError(t, err)
+
+	err = role.Restore(tempRole.Name)
+	require.NoError(t, err)
+
+	found, err := role.GetByName(tempRole.Name)
+	require.NoError(t, err)
+	checkRole(t, tempRole, found)
+
+	err = role.Restore(tempRole.Name)
+	require.ErrorIs(t, err, errs.ErrRoleNotFound)
+
+	err = role.Restore(gofakeit.Name())
+	require.ErrorIs(t, err, errs.ErrRoleNotFound)
+}
+
 func TestRoleWrongDB(t *testing.T) {
 	t.Parallel()
 
@@ -294,10 +394,17 @@ func TestRoleWrongDB(t *testing.T) {
 	require.ErrorContains(t, err, "no such host")
 	require.False(t, found)
 
-	roles, err := role.GetAll(0, 100)
+	roles, err := items(role.GetAll(model.ExcludeDeleted, model.FirstPage(100)))
+	require.ErrorContains(t, err, "no such host")
+	require.Equal(t, roles, model.EmptyRoles)
+
+	roles, err = role.GetByNames([]string{"invalid-role"})
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, roles, model.EmptyRoles)
 
 	err = role.Delete(gofakeit.Name(), time.Now(), model.NewID())
 	require.ErrorContains(t, err, "no such host")
+
+	err = role.Restore(gofakeit.Name())
+	require.ErrorContains(t, err, "no such host")
 }
@@ -0,0 +1,141 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+type TokenSQL struct {
+	database *sqlx.DB
+}
+
+func (t *TokenSQL) GetByID(id model.ID) (model.Token, error) {
+	token := model.TokenPostgres{} //nolint: exhaustruct
+
+	err := t.database.Get(
+		&token,
+		`SELECT 
+			id, userid, name, hash, roles, expires, created_at, created_by, deleted_at, deleted_by
+		FROM users_tokens
+		WHERE deleted_at = $1 AND id = $2`,
+		time.Time{},
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EmptyToken, errs.ErrTokenNotFound
+		}
+
+		return model.EmptyToken, fmt.Errorf("error get token by id in database: %w", err)
+	}
+
+	return token.Token(), nil
+}
+
+func (t *TokenSQL) GetByHash(hash string) (model.Token, error) {
+	token := model.TokenPostgres{} //nolint: exhaustruct
+
+	err := t.database.Get(
+		&token,
+		`SELECT 
+			id, userid, name, hash, roles, expires, created_at, created_by, deleted_at, deleted_by
+		FROM users_tokens
+		WHERE deleted_at = $1 AND hash = $2`,
+		time.Time{},
+		hash,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EmptyToken, errs.ErrTokenNotFound
+		}
+
+		return model.EmptyToken, fmt.Errorf("error get token by hash in database: %w", err)
+	}
+
+	return token.Token(), nil
+}
+
+func (t *TokenSQL) GetByUserID(
+	userID model.ID,
+	pagination model.Pagination,
+) (model.Page[model.Token], error) {
+	query := newListQuery(
+		`SELECT 
+			id, userid, name, hash, roles, expires, created_at, created_by, deleted_at, deleted_by
+		FROM users_tokens`,
+		sortColumn{"created_at", false},
+		sortColumn{"id", false},
+	)
+	query.where("deleted_at = " + query.arg(time.Time{}))
+	query.where("userid = " + query.arg(userID))
+
+	key := func(token model.TokenPostgres) []string {
+		return []string{cursorTime(token.CreatedAt), token.ID.String()}
+	}
+
+	tokens, err := selectPage(t.database, query, pagination, key)
+	if err != nil {
+		return model.EmptyPage[model.Token](), fmt.Errorf("error get tokens by user in database: %w", err)
+	}
+
+	return mapPage(tokens, func(token model.TokenPostgres) model.Token { return token.Token() }), nil
+}
+
+func (t *TokenSQL) Create(token model.Token) error {
+	_, err := t.database.NamedExec(
+		`INSERT INTO users_tokens
+			(id, userid, name, hash, roles, expires, created_at, created_by, deleted_at, deleted_by)
+		VALUES 
+			(:id, :userid, :name, :hash, :roles, :expires, :created_at, :created_by, :deleted_at, :deleted_by)`,
+		token.Postgres(),
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting token: %w", err)
+	}
+
+	return nil
+}
+
+func (t *TokenSQL) Delete(id model.ID, deletedAt time.Time, deletedBy model.ID) error {
+	_, err := t.database.Exec(
+		"UPDATE users_tokens SET deleted_at=$1, deleted_by=$2 WHERE id=$3",
+		deletedAt,
+		deletedBy,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting token: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByUserID revokes all the tokens of the user.
+func (t *TokenSQL) DeleteByUserID(userID model.ID, deletedAt time.Time, deletedBy model.ID) error {
+	_, err := t.database.Exec(
+		"UPDATE users_tokens SET deleted_at=$1, deleted_by=$2 WHERE userid=$3 AND deleted_at=$4",
+		deletedAt,
+		deletedBy,
+		userID,
+		time.Time{},
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting user tokens: %w", err)
+	}
+
+	return nil
+}
+
+var _ Token = &TokenSQL{} //nolint: exhaustruct
+
+func NewTokenSQL(db *sqlx.DB) *TokenSQL {
+	return &TokenSQL{
+		database: db,
+	}
+}
@@ -0,0 +1,124 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+	"github.com/thiago-felipe-99/autenticacao/data"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+func createToken(userID model.ID) model.Token {
+	return model.Token{
+		ID:        model.NewID(),
+		UserID:    userID,
+		Name:      gofakeit.Name(),
+		Hash:      gofakeit.LetterN(64),
+		Roles:     []string{gofakeit.Name()},
+		Expires:   time.Time{},
+		CreatedAt: time.Now(),
+		CreatedBy: userID,
+		DeletedAt: time.Time{},
+		DeletedBy: model.EmptyID,
+	}
+}
+
+func checkToken(t *testing.T, expected, found model.Token) {
+	t.Helper()
+
+	require.Equal(t, expected.ID, found.ID)
+	require.Equal(t, expected.UserID, found.UserID)
+	require.Equal(t, expected.Name, found.Name)
+	require.Equal(t, expected.Hash, found.Hash)
+	require.Equal(t, expected.Roles, found.Roles)
+	require.LessOrEqual(t, expected.Expires.Sub(found.Expires), time.Second)
+	require.LessOrEqual(t, expected.CreatedAt.Sub(found.CreatedAt), time.Second)
+	require.Equal(t, expected.CreatedBy, found.CreatedBy)
+	require.LessOrEqual(t, expected.DeletedAt.Sub(found.DeletedAt), time.Second)
+	require.Equal(t, expected.DeletedBy, found.DeletedBy)
+}
+
+func TestToken(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	qtTokens := 10
+
+	db := createTempDB(t, "data_token")
+	user := data.NewUserSQL(db)
+	token := data.NewTokenSQL(db)
+
+	tempUser := createUser()
+
+	err := user.Create(tempUser)
+	require.NoError(t, err)
+
+	tokens := make([]model.Token, 0, qtTokens)
+
+	for i := 0; i < qtTokens; i++ {
+		tempToken := createToken(tempUser.ID)
+
+		err := token.Create(tempToken)
+		require.NoError(t, err)
+
+		tokens = append(tokens, tempToken)
+	}
+
+	t.Run("GetByID", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tempToken := range tokens {
+			found, err := token.GetByID(tempToken.ID)
+			require.NoError(t, err)
+			checkToken(t, tempToken, found)
+		}
+
+		found, err := token.GetByID(model.NewID())
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+		require.Equal(t, model.EmptyToken, found)
+	})
+
+	t.Run("GetByHash", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tempToken := range tokens {
+			found, err := token.GetByHash(tempToken.Hash)
+			require.NoError(t, err)
+			checkToken(t, tempToken, found)
+		}
+
+		found, err := token.GetByHash("invalid-hash")
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+		require.Equal(t, model.EmptyToken, found)
+	})
+
+	t.Run("GetByUserID", func(t *testing.T) {
+		t.Parallel()
+
+		found, err := items(token.GetByUserID(tempUser.ID, model.FirstPage(qtTokens*2)))
+		require.NoError(t, err)
+		require.Len(t, found, qtTokens)
+
+		found, err = items(token.GetByUserID(model.NewID(), model.FirstPage(qtTokens)))
+		require.NoError(t, err)
+		require.Empty(t, found)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		tempToken := createToken(tempUser.ID)
+
+		err := token.Create(tempToken)
+		require.NoError(t, err)
+
+		err = token.Delete(tempToken.ID, time.Now(), tempUser.ID)
+		require.NoError(t, err)
+
+		_, err = token.GetByID(tempToken.ID)
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+
+		_, err = token.GetByHash(tempToken.Hash)
+		require.ErrorIs(t, err, errs.ErrTokenNotFound)
+	})
+}
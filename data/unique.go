@@ -0,0 +1,25 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const uniqueViolationCode = "23505"
+
+// uniqueViolation maps a unique violation of one of the constraints to its
+// error, other errors are returned as is. The database is the only place the
+// uniqueness can be checked without racing concurrent requests.
+func uniqueViolation(err error, constraints map[string]error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolationCode {
+		return err
+	}
+
+	if constraintErr, ok := constraints[pqErr.Constraint]; ok {
+		return constraintErr
+	}
+
+	return err
+}
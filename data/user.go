@@ -22,7 +22,8 @@ func (u *UserSQL) GetByID(id model.ID) (model.User, error) {
 	err := u.database.Get(
 		&user,
 		`SELECT 
-			id, name, username, email, password, roles, is_active, created_at, created_by, deleted_at, deleted_by
+			id, name, username, email, password, roles, is_active, password_changed_at, must_change_password,
+			created_at, created_by, deleted_at, deleted_by, version
 		FROM users
 		WHERE deleted_at = $1 AND id = $2`,
 		time.Time{},
@@ -45,9 +46,10 @@ func (u *UserSQL) GetByUsername(username string) (model.User, error) {
 	err := u.database.Get(
 		&user,
 		`SELECT 
-			id, name, username, email, password, roles, is_active, created_at, created_by, deleted_at, deleted_by
+			id, name, username, email, password, roles, is_active, password_changed_at, must_change_password,
+			created_at, created_by, deleted_at, deleted_by, version
 		FROM users
-		WHERE deleted_at = $1 AND username = $2`,
+		WHERE deleted_at = $1 AND lower(username) = lower($2)`,
 		time.Time{},
 		username,
 	)
@@ -68,9 +70,10 @@ func (u *UserSQL) GetByEmail(email string) (model.User, error) {
 	err := u.database.Get(
 		&user,
 		`SELECT 
-			id, name, username, email, password, roles, is_active, created_at, created_by, deleted_at, deleted_by
+			id, name, username, email, password, roles, is_active, password_changed_at, must_change_password,
+			created_at, created_by, deleted_at, deleted_by, version
 		FROM users
-		WHERE deleted_at = $1 AND email = $2`,
+		WHERE deleted_at = $1 AND lower(email) = lower($2)`,
 		time.Time{},
 		email,
 	)
@@ -85,87 +88,298 @@ func (u *UserSQL) GetByEmail(email string) (model.User, error) {
 	return user.User(), nil
 }
 
-func (u *UserSQL) GetAll(paginate int, qt int) ([]model.User, error) {
-	partial := make([]model.UserPostgres, 0, qt)
+// userSelection does not load the password hash, like every list query, only
+// the queries of a single user do because they are used to check passwords.
+const userSelection = `SELECT 
+			id, name, username, email, roles, is_active, password_changed_at, must_change_password,
+			created_at, created_by, deleted_at, deleted_by, version
+		FROM users`
 
-	err := u.database.Select(
-		&partial,
-		`SELECT 
-			id, name, username, email, password, roles, is_active, created_at, created_by, deleted_at, deleted_by
-		FROM users
-		LIMIT $1 
-		OFFSET $2`,
-		qt,
-		qt*paginate,
-	)
+// userSortColumn is a column users can be sorted by, key reads the value of
+// the column for the cursor.
+type userSortColumn struct {
+	column string
+	key    func(user model.UserPostgres) string
+}
+
+// userSortColumns is the only source of the columns used in ORDER BY, so sort
+// fields never reach the query text.
+var userSortColumns = map[string]userSortColumn{ //nolint: gochecknoglobals
+	model.UserSortName: {
+		column: "name",
+		key:    func(user model.UserPostgres) string { return user.Name },
+	},
+	model.UserSortUsername: {
+		column: "username",
+		key:    func(user model.UserPostgres) string { return user.Username },
+	},
+	model.UserSortEmail: {
+		column: "email",
+		key:    func(user model.UserPostgres) string { return user.Email },
+	},
+	model.UserSortCreatedAt: {
+		column: "created_at",
+		key:    func(user model.UserPostgres) string { return cursorTime(user.CreatedAt) },
+	},
+	model.UserSortPasswordChangedAt: {
+		column: "password_changed_at",
+		key:    func(user model.UserPostgres) string { return cursorTime(user.PasswordChangedAt) },
+	},
+}
+
+// selectUsers selects a page of users sorted by the sorts, or by creation
+// without them, with id breaking the ties.
+func (u *UserSQL) selectUsers(
+	query *listQuery,
+	sorts []model.UserSort,
+	pagination model.Pagination,
+) (model.Page[model.User], error) {
+	if len(sorts) == 0 {
+		sorts = []model.UserSort{{Field: model.UserSortCreatedAt, Descending: false}}
+	}
+
+	keys := make([]func(model.UserPostgres) string, 0, len(sorts))
+
+	for _, sort := range sorts {
+		column, ok := userSortColumns[sort.Field]
+		if !ok {
+			return model.EmptyPage[model.User](), fmt.Errorf("%w: %s", errs.ErrInvalidSort, sort.Field)
+		}
+
+		query.orders = append(query.orders, sortColumn{column.column, sort.Descending})
+		keys = append(keys, column.key)
+	}
+
+	query.orders = append(query.orders, sortColumn{"id", false})
+
+	key := func(user model.UserPostgres) []string {
+		values := make([]string, 0, len(keys)+1)
+		for _, key := range keys {
+			values = append(values, key(user))
+		}
+
+		return append(values, user.ID.String())
+	}
+
+	page, err := selectPage(u.database, query, pagination, key)
+	if err != nil {
+		return model.EmptyPage[model.User](), err
+	}
+
+	return mapPage(page, func(user model.UserPostgres) model.User { return user.User() }), nil
+}
+
+func (u *UserSQL) GetAll(
+	deleted model.Deleted,
+	pagination model.Pagination,
+) (model.Page[model.User], error) {
+	query := newListQuery(userSelection)
+	query.deleted("deleted_at", deleted)
+
+	users, err := u.selectUsers(query, nil, pagination)
 	if err != nil {
-		return model.EmptyUsers, fmt.Errorf("error get users in database: %w", err)
+		return model.EmptyPage[model.User](), fmt.Errorf("error get users in database: %w", err)
 	}
 
-	users := make([]model.User, 0, len(partial))
-	for _, user := range partial {
-		users = append(users, user.User())
+	return users, nil
+}
+
+func (u *UserSQL) Search(
+	filter model.UserFilter,
+	pagination model.Pagination,
+) (model.Page[model.User], error) {
+	query := newListQuery(userSelection)
+	query.deleted("deleted_at", filter.Deleted)
+
+	query.contains("name", filter.Name)
+	query.contains("username", filter.Username)
+	query.contains("email", filter.Email)
+
+	if filter.IsActive != nil {
+		query.where("is_active = " + query.arg(*filter.IsActive))
+	}
+
+	if filter.Role != "" {
+		query.where("roles @> " + query.arg(pq.StringArray{filter.Role}))
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		query.where("created_at >= " + query.arg(filter.CreatedFrom))
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		query.where("created_at <= " + query.arg(filter.CreatedTo))
+	}
+
+	if filter.CreatedBy != model.EmptyID {
+		query.where("created_by = " + query.arg(filter.CreatedBy))
+	}
+
+	users, err := u.selectUsers(query, filter.Sort, pagination)
+	if err != nil {
+		return model.EmptyPage[model.User](), fmt.Errorf("error searching users in database: %w", err)
 	}
 
 	return users, nil
 }
 
-func (u *UserSQL) GetByRoles(roles []string, paginate int, qt int) ([]model.User, error) {
-	partial := make([]model.UserPostgres, 0, qt)
+func (u *UserSQL) GetByRoles(
+	roles []string,
+	deleted model.Deleted,
+	pagination model.Pagination,
+) (model.Page[model.User], error) {
+	query := newListQuery(userSelection)
+	query.deleted("deleted_at", deleted)
+	query.where("roles @> " + query.arg(pq.StringArray(roles)))
+
+	users, err := u.selectUsers(query, nil, pagination)
+	if err != nil {
+		return model.EmptyPage[model.User](), fmt.Errorf("error get users by role in database: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetSummaries gets the summaries of many users in a single query, including
+// deleted users, since they can still be referenced by other resources.
+func (u *UserSQL) GetSummaries(ids []model.ID) ([]model.UserSummary, error) {
+	idsRaw := make([]string, 0, len(ids))
+	for _, id := range ids {
+		idsRaw = append(idsRaw, id.String())
+	}
+
+	summaries := make([]model.UserSummary, 0, len(ids))
 
 	err := u.database.Select(
-		&partial,
-		`SELECT 
-			id, name, username, email, password, roles, is_active, created_at, created_by, deleted_at, deleted_by
+		&summaries,
+		`SELECT id, name, username
 		FROM users
-		WHERE roles @> $1
-		LIMIT $2 
-		OFFSET $3`,
-		pq.StringArray(roles),
-		qt,
-		qt*paginate,
+		WHERE id = ANY($1::uuid[])`,
+		pq.StringArray(idsRaw),
 	)
 	if err != nil {
-		return model.EmptyUsers, fmt.Errorf("error get users by role in database: %w", err)
+		return []model.UserSummary{}, fmt.Errorf("error get users summaries in database: %w", err)
 	}
 
-	users := make([]model.User, 0, len(partial))
-	for _, user := range partial {
-		users = append(users, user.User())
-	}
+	return summaries, nil
+}
 
-	return users, nil
+// userConstraints are the unique indexes of the users that are not deleted,
+// they ignore the case of usernames and emails.
+var userConstraints = map[string]error{ //nolint: gochecknoglobals
+	"users_username_lower_not_deleted_idx": errs.ErrUsernameAlreadyExist,
+	"users_email_lower_not_deleted_idx":    errs.ErrEmailAlreadyExist,
 }
 
 func (u *UserSQL) Create(user model.User) error {
 	_, err := u.database.NamedExec(
 		`INSERT INTO users
-			(id, name, username, email, password, roles, is_active, created_at, created_by, deleted_at, deleted_by)
+			(id, name, username, email, password, roles, is_active, password_changed_at, must_change_password,
+			created_at, created_by, deleted_at, deleted_by)
 		VALUES 
-			(:id, :name, :username, :email, :password, :roles, :is_active, :created_at, :created_by, :deleted_at, :deleted_by)`,
+			(:id, :name, :username, :email, :password, :roles, :is_active, :password_changed_at, :must_change_password,
+			:created_at, :created_by, :deleted_at, :deleted_by)`,
 		user.Postgres(),
 	)
 	if err != nil {
-		return fmt.Errorf("error inserting user: %w", err)
+		return fmt.Errorf("error inserting user: %w", uniqueViolation(err, userConstraints))
 	}
 
 	return nil
 }
 
-func (u *UserSQL) Update(user model.User) error {
-	_, err := u.database.NamedExec(
+func (u *UserSQL) GetPasswords(id model.ID, qt int) ([]string, error) {
+	passwords := make([]string, 0, qt)
+
+	err := u.database.Select(
+		&passwords,
+		`SELECT password
+		FROM users_passwords
+		WHERE userid = $1
+		ORDER BY replaced_at DESC
+		LIMIT $2`,
+		id,
+		qt,
+	)
+	if err != nil {
+		return []string{}, fmt.Errorf("error get user passwords in database: %w", err)
+	}
+
+	return passwords, nil
+}
+
+// Update writes the user when it still has the version it was read with,
+// returning errs.ErrUserVersionMismatch when another write happened since.
+func (u *UserSQL) Update(user model.User) (err error) {
+	tx, err := u.database.Beginx()
+	if err != nil {
+		return fmt.Errorf("error beging transaction: %w", err)
+	}
+
+	defer func(tx *sqlx.Tx) {
+		if err != nil {
+			newErr := tx.Rollback()
+			if newErr != nil {
+				err = fmt.Errorf("error roolback transaction: %w", errors.Join(newErr, err))
+			}
+		}
+	}(tx)
+
+	_, err = tx.Exec(
+		`INSERT INTO users_passwords (userid, password, replaced_at)
+		SELECT id, password, $1 FROM users
+		WHERE id = $2 AND password <> $3`,
+		time.Now(),
+		user.ID,
+		user.Password,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving user password history: %w", err)
+	}
+
+	result, err := tx.NamedExec(
 		`UPDATE users SET
 			name = :name, 
 			username = :username, 
 			email = :email, 
 			password = :password, 
 			roles = :roles, 
-			is_active = :is_active
-		WHERE id = :id`,
+			is_active = :is_active,
+			password_changed_at = :password_changed_at,
+			must_change_password = :must_change_password,
+			version = version + 1
+		WHERE id = :id AND version = :version`,
 		user.Postgres(),
 	)
 	if err != nil {
-		return fmt.Errorf("error updating user: %w", err)
+		return fmt.Errorf("error updating user: %w", uniqueViolation(err, userConstraints))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting updated users: %w", err)
+	}
+
+	if rows == 0 {
+		return errs.ErrUserVersionMismatch
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (u *UserSQL) UpdatePassword(id model.ID, password string) error {
+	_, err := u.database.Exec(
+		"UPDATE users SET password=$1, version=version+1 WHERE id=$2",
+		password,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating user password: %w", err)
 	}
 
 	return nil
@@ -173,7 +387,7 @@ func (u *UserSQL) Update(user model.User) error {
 
 func (u *UserSQL) Delete(id model.ID, deletedAt time.Time, deletedBy model.ID) error {
 	_, err := u.database.Exec(
-		"UPDATE users SET deleted_at=$1, deleted_by=$2 WHERE id=$3",
+		"UPDATE users SET deleted_at=$1, deleted_by=$2, version=version+1 WHERE id=$3",
 		deletedAt,
 		deletedBy,
 		id,
@@ -185,6 +399,32 @@ func (u *UserSQL) Delete(id model.ID, deletedAt time.Time, deletedBy model.ID) e
 	return nil
 }
 
+// Restore undoes the soft delete of the user, returning errs.ErrUserNotFound
+// when there is no deleted user with the id.
+func (u *UserSQL) Restore(id model.ID) error {
+	result, err := u.database.Exec(
+		`UPDATE users SET deleted_at=$1, deleted_by=$2, version=version+1
+		WHERE id=$3 AND deleted_at <> $1`,
+		time.Time{},
+		model.EmptyID,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("error restoring user: %w", uniqueViolation(err, userConstraints))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting restored users: %w", err)
+	}
+
+	if rows == 0 {
+		return errs.ErrUserNotFound
+	}
+
+	return nil
+}
+
 var _ User = &UserSQL{} //nolint: exhaustruct
 
 func NewUserSQL(db *sqlx.DB) *UserSQL {
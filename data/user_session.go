@@ -20,6 +20,138 @@ var (
 	ErrInsertingUserSessionDB = fmt.Errorf("error inserting user session in db")
 )
 
+// extendScript extends the TTL of KEYS[index] to at least ttl milliseconds.
+const extendScript = `
+local function extend(index, ttl)
+	if redis.call('PTTL', KEYS[index]) < tonumber(ttl) then
+		redis.call('PEXPIRE', KEYS[index], ttl)
+	end
+end
+`
+
+// createScript stores the session KEYS[1] and adds it to the sessions index of
+// its owner KEYS[2], scored by its original creation. When a limit is given,
+// expired sessions are removed from the index and, if the user already has
+// limit sessions, the creation is rejected or the oldest sessions are evicted.
+// Sessions are read dynamically from the index, which requires a single Redis
+// node.
+//
+//nolint:gochecknoglobals
+var createScript = redis.NewScript(extendScript + `
+local limit = tonumber(ARGV[4])
+local evicted = {1}
+
+if limit > 0 then
+	for _, id in ipairs(redis.call('ZRANGE', KEYS[2], 0, -1)) do
+		if redis.call('EXISTS', id) == 0 then
+			redis.call('ZREM', KEYS[2], id)
+		end
+	end
+
+	local count = redis.call('ZCARD', KEYS[2])
+	if count >= limit then
+		if ARGV[5] ~= '1' then
+			return {0}
+		end
+
+		for _, id in ipairs(redis.call('ZRANGE', KEYS[2], 0, count - limit)) do
+			local value = redis.call('GET', id)
+			redis.call('DEL', id)
+			redis.call('ZREM', KEYS[2], id)
+			if value then
+				table.insert(evicted, value)
+			end
+		end
+	end
+end
+
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], KEYS[1])
+extend(2, ARGV[2])
+
+return evicted
+`)
+
+// rotateScript replaces the session KEYS[1] by KEYS[2], also in the user
+// sessions index KEYS[4], and, when a grace period is given, keeps in KEYS[3]
+// a pointer from the old session to the new one. If the session was already
+// rotated the current successor is returned instead, so concurrent rotations
+// of the same session agree on the result. The successor key is read
+// dynamically, which requires a single Redis node.
+//
+//nolint:gochecknoglobals
+var rotateScript = redis.NewScript(extendScript + `
+local successor = redis.call('GET', KEYS[3])
+if successor then
+	local value = redis.call('GET', successor)
+	if not value then
+		return nil
+	end
+	return {0, value}
+end
+
+local old = redis.call('GET', KEYS[1])
+if not old then
+	return nil
+end
+
+redis.call('DEL', KEYS[1])
+redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+redis.call('ZREM', KEYS[4], KEYS[1])
+redis.call('ZADD', KEYS[4], ARGV[4], KEYS[2])
+extend(4, ARGV[2])
+
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[3], KEYS[2], 'PX', ARGV[3])
+end
+
+return {1, old}
+`)
+
+// deleteAllScript deletes every session of the index KEYS[1] and the index,
+// returning the deleted sessions. Sessions are read dynamically from the
+// index, which requires a single Redis node.
+//
+//nolint:gochecknoglobals
+var deleteAllScript = redis.NewScript(`
+local deleted = {}
+
+for _, id in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	local value = redis.call('GET', id)
+	redis.call('DEL', id)
+	if value then
+		table.insert(deleted, value)
+	end
+end
+
+redis.call('DEL', KEYS[1])
+
+return deleted
+`)
+
+func successorKey(id model.ID) string {
+	return "successor:" + id.String()
+}
+
+func userSessionsKey(userID model.ID) string {
+	return "user_sessions:" + userID.String()
+}
+
+// sessionOwner returns who uses the session, the impersonator for impersonated
+// sessions, so they do not count in the session limit of the impersonated user
+// nor are listed as their sessions.
+func sessionOwner(userSession model.UserSession) model.ID {
+	if userSession.ImpersonatorID != model.EmptyID {
+		return userSession.ImpersonatorID
+	}
+
+	return userSession.UserID
+}
+
+func sessionTTL(userSession model.UserSession) int64 {
+	return max(time.Until(userSession.Expires).Milliseconds(), 1)
+}
+
 type UserSessionRedis struct {
 	redis      *redis.Client
 	database   *sqlx.DB
@@ -49,118 +181,156 @@ func (u *UserSessionRedis) GetByID(id model.ID) (model.UserSession, error) {
 	return userSession, nil
 }
 
-func (u *UserSessionRedis) GetAllActive(paginate int, qt int) ([]model.UserSession, error) {
-	userSessions := make([]model.UserSession, qt)
-
-	err := u.database.Select(
-		&userSessions,
-		`SELECT uc.id, uc.userid, uc.created_at, uc.expires, uc.deleted_at
+func activeUserSessionsQuery() *listQuery {
+	query := newListQuery(
+		`SELECT uc.id, uc.userid, uc.created_at, uc.original_created_at, uc.expires, uc.deleted_at,
+		uc.restricted, uc.remember_me, uc.authenticated_at, uc.authentication_method, uc.impersonator_id,
+		uc.ip, uc.user_agent, uc.device, uc.device_name
 		FROM users_sessions_created uc
 		LEFT JOIN users_sessions_deleted ud
-		ON uc.id = ud.id 
-		WHERE ud.id IS NULL AND now() < uc.expires
-		LIMIT $1 
-		OFFSET $2`,
-		qt,
-		qt*paginate,
+		ON uc.id = ud.id`,
+		sortColumn{"uc.created_at", false},
+		sortColumn{"uc.id", false},
 	)
-	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf("error get user sessions in database: %w", err)
-	}
+	query.where("ud.id IS NULL")
+	query.where("now() < uc.expires")
 
-	return userSessions, nil
+	return query
 }
 
-func (u *UserSessionRedis) GetByUserIDActive(
-	id model.ID,
-	paginate int,
-	qt int,
-) ([]model.UserSession, error) {
-	userSessions := make([]model.UserSession, qt)
-
-	err := u.database.Select(
-		&userSessions,
-		`SELECT uc.id, uc.userid, uc.created_at, uc.expires, uc.deleted_at
-		FROM users_sessions_created uc
-		LEFT JOIN users_sessions_deleted ud
-		ON uc.id = ud.id 
-		WHERE ud.id IS NULL AND now() < uc.expires AND uc.userid = $1
-		LIMIT $2 
-		OFFSET $3`,
-		id,
-		qt,
-		qt*paginate,
+func inactiveUserSessionsQuery() *listQuery {
+	return newListQuery(
+		`SELECT ud.id, ud.userid, ud.created_at, ud.original_created_at, ud.expires, ud.deleted_at,
+		ud.restricted, ud.remember_me, ud.authenticated_at, ud.authentication_method, ud.impersonator_id,
+		ud.ip, ud.user_agent, ud.device, ud.device_name
+		FROM users_sessions_deleted ud
+		LEFT JOIN users_sessions_created uc
+		ON ud.id = uc.id`,
+		sortColumn{"ud.created_at", false},
+		sortColumn{"ud.id", false},
 	)
+}
+
+func (u *UserSessionRedis) selectUserSessions(
+	query *listQuery,
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	key := func(userSession model.UserSession) []string {
+		return []string{cursorTime(userSession.CreateaAt), userSession.ID.String()}
+	}
+
+	userSessions, err := selectPage(u.database, query, pagination, key)
 	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf("error get user sessions in database: %w", err)
+		return model.EmptyPage[model.UserSession](),
+			fmt.Errorf("error get user sessions in database: %w", err)
 	}
 
 	return userSessions, nil
 }
 
-func (u *UserSessionRedis) GetAllInactive(paginate int, qt int) ([]model.UserSession, error) {
-	userSessions := make([]model.UserSession, qt)
+func (u *UserSessionRedis) GetAllActive(
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	return u.selectUserSessions(activeUserSessionsQuery(), pagination)
+}
 
-	err := u.database.Select(
-		&userSessions,
-		`SELECT ud.id, ud.userid, ud.created_at, ud.expires, ud.deleted_at
-		FROM users_sessions_deleted ud
-		LEFT JOIN users_sessions_created uc
-		ON ud.id = uc.id 
-		LIMIT $1 
-		OFFSET $2`,
-		qt,
-		qt*paginate,
+func (u *UserSessionRedis) GetByUserIDActive(
+	id model.ID,
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	query := activeUserSessionsQuery()
+	query.where(
+		"COALESCE(NULLIF(uc.impersonator_id, " + query.arg(model.EmptyID) + "), uc.userid) = " +
+			query.arg(id),
 	)
-	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf("error get user sessions in database: %w", err)
-	}
 
-	return userSessions, nil
+	return u.selectUserSessions(query, pagination)
+}
+
+func (u *UserSessionRedis) GetAllInactive(
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	return u.selectUserSessions(inactiveUserSessionsQuery(), pagination)
 }
 
 func (u *UserSessionRedis) GetByUserIDInactive(
 	id model.ID,
-	paginate int,
-	qt int,
-) ([]model.UserSession, error) {
-	userSessions := make([]model.UserSession, qt)
-
-	err := u.database.Select(
-		&userSessions,
-		`SELECT ud.id, ud.userid, ud.created_at, ud.expires, ud.deleted_at
-		FROM users_sessions_deleted ud
-		LEFT JOIN users_sessions_created uc
-		ON ud.id = uc.id 
-		WHERE ud.userid = $1
-		LIMIT $2 
-		OFFSET $3`,
-		id,
-		qt,
-		qt*paginate,
+	pagination model.Pagination,
+) (model.Page[model.UserSession], error) {
+	query := inactiveUserSessionsQuery()
+	query.where(
+		"COALESCE(NULLIF(ud.impersonator_id, " + query.arg(model.EmptyID) + "), ud.userid) = " +
+			query.arg(id),
 	)
-	if err != nil {
-		return model.EmptyUserSessions, fmt.Errorf("error get user sessions in database: %w", err)
-	}
 
-	return userSessions, nil
+	return u.selectUserSessions(query, pagination)
 }
 
 func (u *UserSessionRedis) Create(userSession model.UserSession) error {
+	_, err := u.CreateWithLimit(userSession, 0, false)
+
+	return err
+}
+
+// CreateWithLimit creates the session unless the user already has limit active
+// sessions, returning errs.ErrUserSessionLimit or, when evict is true, deleting
+// and returning the oldest sessions to make room. A limit of 0 means no limit.
+func (u *UserSessionRedis) CreateWithLimit(
+	userSession model.UserSession,
+	limit int,
+	evict bool,
+) ([]model.UserSession, error) {
 	serial, err := msgpack.Marshal(&userSession)
 	if err != nil {
-		return fmt.Errorf("error marshaling user session: %w", err)
+		return model.EmptyUserSessions, fmt.Errorf("error marshaling user session: %w", err)
 	}
 
-	_, err = u.redis.Set(context.Background(), userSession.ID.String(), serial, time.Since(userSession.Expires)).
-		Result()
+	evictFlag := 0
+	if evict {
+		evictFlag = 1
+	}
+
+	result, err := createScript.Run(
+		context.Background(),
+		u.redis,
+		[]string{userSession.ID.String(), userSessionsKey(sessionOwner(userSession))},
+		serial,
+		sessionTTL(userSession),
+		userSession.OriginalCreatedAt.UnixMicro(),
+		limit,
+		evictFlag,
+	).Slice()
 	if err != nil {
-		return fmt.Errorf("error setting user session in redis: %w", err)
+		return model.EmptyUserSessions, fmt.Errorf("error setting user session in redis: %w", err)
+	}
+
+	created, _ := result[0].(int64)
+	if created == 0 {
+		return model.EmptyUserSessions, errs.ErrUserSessionLimit
+	}
+
+	evicted := make([]model.UserSession, 0, len(result)-1)
+	deletedAt := time.Now()
+
+	for _, value := range result[1:] {
+		var evictedSession model.UserSession
+
+		serial, _ := value.(string)
+
+		err = msgpack.Unmarshal([]byte(serial), &evictedSession)
+		if err != nil {
+			return model.EmptyUserSessions, fmt.Errorf("error unmarshaling user session: %w", err)
+		}
+
+		evictedSession.DeletedAt = deletedAt
+		u.deleted <- evictedSession
+
+		evicted = append(evicted, evictedSession)
 	}
 
 	u.created <- userSession
 
-	return nil
+	return evicted, nil
 }
 
 func (u *UserSessionRedis) Delete(id model.ID, deletetAd time.Time) (model.UserSession, error) {
@@ -180,12 +350,130 @@ func (u *UserSessionRedis) Delete(id model.ID, deletetAd time.Time) (model.UserS
 		return model.EmptyUserSession, fmt.Errorf("error unmarshaling user session: %w", err)
 	}
 
+	key := userSessionsKey(sessionOwner(userSession))
+
+	err = u.redis.ZRem(context.Background(), key, id.String()).Err()
+	if err != nil {
+		return model.EmptyUserSession, fmt.Errorf("error removing user session from index: %w", err)
+	}
+
 	userSession.DeletedAt = deletetAd
 	u.deleted <- userSession
 
 	return userSession, nil
 }
 
+// DeleteByUserID deletes all the sessions of the user, including the ones
+// impersonating other users.
+func (u *UserSessionRedis) DeleteByUserID(
+	userID model.ID,
+	deletedAt time.Time,
+) ([]model.UserSession, error) {
+	result, err := deleteAllScript.Run(
+		context.Background(),
+		u.redis,
+		[]string{userSessionsKey(userID)},
+	).StringSlice()
+	if err != nil {
+		return model.EmptyUserSessions, fmt.Errorf("error deleting user sessions in redis: %w", err)
+	}
+
+	deleted := make([]model.UserSession, 0, len(result))
+
+	for _, serial := range result {
+		var userSession model.UserSession
+
+		err = msgpack.Unmarshal([]byte(serial), &userSession)
+		if err != nil {
+			return model.EmptyUserSessions, fmt.Errorf("error unmarshaling user session: %w", err)
+		}
+
+		userSession.DeletedAt = deletedAt
+		u.deleted <- userSession
+
+		deleted = append(deleted, userSession)
+	}
+
+	return deleted, nil
+}
+
+// Rotate atomically replaces the session id by next. During the grace period
+// the old id can still be rotated or read through GetSuccessor, always
+// resulting in the same successor session.
+func (u *UserSessionRedis) Rotate(
+	id model.ID,
+	next model.UserSession,
+	deletedAt time.Time,
+	grace time.Duration,
+) (model.UserSession, error) {
+	serial, err := msgpack.Marshal(&next)
+	if err != nil {
+		return model.EmptyUserSession, fmt.Errorf("error marshaling user session: %w", err)
+	}
+
+	result, err := rotateScript.Run(
+		context.Background(),
+		u.redis,
+		[]string{
+			id.String(),
+			next.ID.String(),
+			successorKey(id),
+			userSessionsKey(sessionOwner(next)),
+		},
+		serial,
+		sessionTTL(next),
+		grace.Milliseconds(),
+		next.OriginalCreatedAt.UnixMicro(),
+	).Slice()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return model.EmptyUserSession, errs.ErrUserSessionNotFound
+		}
+
+		return model.EmptyUserSession, fmt.Errorf("error rotating user session in redis: %w", err)
+	}
+
+	rotated, _ := result[0].(int64)
+	value, _ := result[1].(string)
+
+	var userSession model.UserSession
+
+	err = msgpack.Unmarshal([]byte(value), &userSession)
+	if err != nil {
+		return model.EmptyUserSession, fmt.Errorf("error unmarshaling user session: %w", err)
+	}
+
+	if rotated == 0 {
+		return userSession, nil
+	}
+
+	userSession.DeletedAt = deletedAt
+	u.deleted <- userSession
+	u.created <- next
+
+	return next, nil
+}
+
+// GetSuccessor returns the session that replaced id if it was rotated less
+// than a grace period ago.
+func (u *UserSessionRedis) GetSuccessor(id model.ID) (model.UserSession, error) {
+	successor, err := u.redis.Get(context.Background(), successorKey(id)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return model.EmptyUserSession, errs.ErrUserSessionNotFound
+		}
+
+		return model.EmptyUserSession, fmt.Errorf("error getting user session from redis: %w", err)
+	}
+
+	successorID, err := model.ParseID(successor)
+	if err != nil {
+		return model.EmptyUserSession, fmt.Errorf("error parsing user session successor: %w", err)
+	}
+
+	return u.GetByID(successorID)
+}
+
 func (u *UserSessionRedis) consumeChan(
 	clock time.Duration,
 	max int,
@@ -197,8 +485,12 @@ func (u *UserSessionRedis) consumeChan(
 	usersSessions := make([]model.UserSession, 0, max)
 
 	query := fmt.Sprintf(
-		`INSERT INTO %s (id, userid, created_at, expires, deleted_at) 
-		VALUES (:id, :userid, :created_at, :expires, :deleted_at)`,
+		`INSERT INTO %s (id, userid, created_at, original_created_at, expires, deleted_at,
+		restricted, remember_me, authenticated_at, authentication_method, impersonator_id,
+		ip, user_agent, device, device_name)
+		VALUES (:id, :userid, :created_at, :original_created_at, :expires, :deleted_at,
+		:restricted, :remember_me, :authenticated_at, :authentication_method, :impersonator_id,
+		:ip, :user_agent, :device, :device_name)`,
 		table,
 	)
 
@@ -232,18 +524,32 @@ func (u *UserSessionRedis) consumeChan(
 	}
 }
 
+func logExpiredImpersonations(usersSessions []model.UserSession) {
+	for _, userSession := range usersSessions {
+		if userSession.ImpersonatorID != model.EmptyID {
+			LogImpersonationEnd(userSession, "session expired")
+		}
+	}
+}
+
 func (u *UserSessionRedis) expiredUserSessions(clock time.Duration, max int) {
 	ticker := time.NewTicker(clock)
 
-	getInactives := `SELECT uc.id, uc.userid, uc.created_at, uc.expires, uc.deleted_at
+	getInactives := `SELECT uc.id, uc.userid, uc.created_at, uc.original_created_at, uc.expires, uc.deleted_at,
+	uc.restricted, uc.remember_me, uc.authenticated_at, uc.authentication_method, uc.impersonator_id,
+	uc.ip, uc.user_agent, uc.device, uc.device_name
 	FROM users_sessions_created uc
 	LEFT JOIN users_sessions_deleted ud
 	ON uc.id = ud.id 
 	WHERE ud.id IS NULL AND now() > uc.expires
 	LIMIT ` + fmt.Sprint(max)
 
-	insertInactives := `INSERT INTO users_sessions_deleted (id, userid, created_at, expires, deleted_at) 
-	VALUES (:id, :userid, :created_at, :expires, :deleted_at)`
+	insertInactives := `INSERT INTO users_sessions_deleted (id, userid, created_at, original_created_at, expires, deleted_at,
+	restricted, remember_me, authenticated_at, authentication_method, impersonator_id,
+	ip, user_agent, device, device_name)
+	VALUES (:id, :userid, :created_at, :original_created_at, :expires, :deleted_at,
+	:restricted, :remember_me, :authenticated_at, :authentication_method, :impersonator_id,
+	:ip, :user_agent, :device, :device_name)`
 
 	for range ticker.C {
 		usersSessions := make([]model.UserSession, 0, max)
@@ -259,6 +565,8 @@ func (u *UserSessionRedis) expiredUserSessions(clock time.Duration, max int) {
 				u.errs <- errors.Join(ErrInsertingUserSessionDB, err)
 			}
 
+			logExpiredImpersonations(usersSessions)
+
 			// does not use nil because the underlying memory will be marked for removal by the GC,
 			// with this method the memory is not marked and the capacity is kept
 			usersSessions = usersSessions[:0]
@@ -274,6 +582,8 @@ func (u *UserSessionRedis) expiredUserSessions(clock time.Duration, max int) {
 			if err != nil {
 				u.errs <- errors.Join(ErrInsertingUserSessionDB, err)
 			}
+
+			logExpiredImpersonations(usersSessions)
 		}
 	}
 }
@@ -294,6 +604,18 @@ func (u *UserSessionRedis) ConsumeQueues(clock time.Duration, max int) error {
 	return nil
 }
 
+// LogImpersonationEnd logs that an impersonated session is gone and why, the
+// start is logged when the session is created.
+func LogImpersonationEnd(userSession model.UserSession, reason string) {
+	log.Printf(
+		"[INFO] - User '%s' stopped impersonating user '%s' with session '%s': %s",
+		userSession.ImpersonatorID,
+		userSession.UserID,
+		userSession.ID,
+		reason,
+	)
+}
+
 func (u *UserSessionRedis) Errors() <-chan error {
 	return u.errs
 }
@@ -2,6 +2,7 @@ package data_test
 
 import (
 	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,12 +21,13 @@ func logErrors(t *testing.T, errs <-chan error) {
 }
 
 func createUserSession(userID model.ID) model.UserSession {
-	return model.UserSession{
-		ID:        model.NewID(),
-		UserID:    userID,
-		CreateaAt: time.Now(),
-		Expires:   time.Now().Add(time.Second * 2),
-		DeletedAt: time.Time{},
+	return model.UserSession{ //nolint:exhaustruct
+		ID:                model.NewID(),
+		UserID:            userID,
+		CreateaAt:         time.Now(),
+		OriginalCreatedAt: time.Now(),
+		Expires:           time.Now().Add(time.Second * 2),
+		DeletedAt:         time.Time{},
 	}
 }
 
@@ -55,19 +57,27 @@ func TestUserSession(t *testing.T) { //nolint:funlen
 	err = user.Create(userTemp)
 	require.NoError(t, err)
 
-	usersSessionsAllActive, err := userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsAllActive, err := items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsAllActive)
 
-	usersSessionsAllInactive, err := userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsAllInactive, err := items(userSession.GetAllInactive(
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsAllInactive)
 
-	usersSessionsIDActive, err := userSession.GetByUserIDActive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDActive, err := items(userSession.GetByUserIDActive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsIDActive)
 
-	usersSessionsIDInactive, err := userSession.GetByUserIDInactive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDInactive, err := items(userSession.GetByUserIDInactive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsIDInactive)
 
@@ -93,19 +103,25 @@ func TestUserSession(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second)
 
-	usersSessionsAllActive, err = userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsAllActive, err = items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsAllActive))
 
-	usersSessionsAllInactive, err = userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsAllInactive, err = items(userSession.GetAllInactive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsAllInactive)
 
-	usersSessionsIDActive, err = userSession.GetByUserIDActive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDActive, err = items(userSession.GetByUserIDActive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsIDActive))
 
-	usersSessionsIDInactive, err = userSession.GetByUserIDInactive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDInactive, err = items(userSession.GetByUserIDInactive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsIDInactive)
 
@@ -132,19 +148,25 @@ func TestUserSession(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second)
 
-	usersSessionsAllActive, err = userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsAllActive, err = items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsAllActive)
 
-	usersSessionsAllInactive, err = userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsAllInactive, err = items(userSession.GetAllInactive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsAllInactive))
 
-	usersSessionsIDActive, err = userSession.GetByUserIDActive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDActive, err = items(userSession.GetByUserIDActive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsIDActive)
 
-	usersSessionsIDInactive, err = userSession.GetByUserIDInactive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDInactive, err = items(userSession.GetByUserIDInactive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsIDInactive))
 
@@ -215,19 +237,27 @@ func TestUserSessionExpire(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second)
 
-	usersSessionsAllActive, err := userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsAllActive, err := items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsAllActive))
 
-	usersSessionsAllInactive, err := userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsAllInactive, err := items(userSession.GetAllInactive(
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsAllInactive)
 
-	usersSessionsIDActive, err := userSession.GetByUserIDActive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDActive, err := items(userSession.GetByUserIDActive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsIDActive))
 
-	usersSessionsIDInactive, err := userSession.GetByUserIDInactive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDInactive, err := items(userSession.GetByUserIDInactive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsIDInactive)
 
@@ -251,19 +281,25 @@ func TestUserSessionExpire(t *testing.T) { //nolint:funlen
 
 	time.Sleep(time.Second * 3)
 
-	usersSessionsAllActive, err = userSession.GetAllActive(0, qtUsersSessions)
+	usersSessionsAllActive, err = items(userSession.GetAllActive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsAllActive)
 
-	usersSessionsAllInactive, err = userSession.GetAllInactive(0, qtUsersSessions)
+	usersSessionsAllInactive, err = items(userSession.GetAllInactive(model.FirstPage(qtUsersSessions)))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsAllInactive))
 
-	usersSessionsIDActive, err = userSession.GetByUserIDActive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDActive, err = items(userSession.GetByUserIDActive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, model.EmptyUserSessions, usersSessionsIDActive)
 
-	usersSessionsIDInactive, err = userSession.GetByUserIDInactive(userTemp.ID, 0, qtUsersSessions)
+	usersSessionsIDInactive, err = items(userSession.GetByUserIDInactive(
+		userTemp.ID,
+		model.FirstPage(qtUsersSessions),
+	))
 	require.NoError(t, err)
 	require.Equal(t, qtUsersSessions, len(usersSessionsIDInactive))
 
@@ -279,6 +315,257 @@ func TestUserSessionExpire(t *testing.T) { //nolint:funlen
 	}
 }
 
+func TestUserSessionRotate(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "data_user_session_rotate")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 250
+	qtRequests := 50
+
+	user := data.NewUserSQL(db)
+	userSession := data.NewUserSessionRedis(redisClient, db, buffer)
+	err := userSession.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+
+	go logErrors(t, userSession.Errors())
+
+	userTemp := createUser()
+	err = user.Create(userTemp)
+	require.NoError(t, err)
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		old := createUserSession(userTemp.ID)
+		err := userSession.Create(old)
+		require.NoError(t, err)
+
+		successors := make([]model.ID, qtRequests)
+		wait := sync.WaitGroup{}
+
+		for i := 0; i < qtRequests; i++ {
+			wait.Add(1)
+
+			go func(i int) {
+				defer wait.Done()
+
+				next := createUserSession(userTemp.ID)
+
+				successor, err := userSession.Rotate(old.ID, next, time.Now(), time.Second)
+				if err != nil {
+					t.Error(err)
+				}
+
+				successors[i] = successor.ID
+			}(i)
+		}
+
+		wait.Wait()
+
+		for _, successor := range successors {
+			require.Equal(t, successors[0], successor)
+		}
+
+		_, err = userSession.GetByID(old.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		successor, err := userSession.GetSuccessor(old.ID)
+		require.NoError(t, err)
+		require.Equal(t, successors[0], successor.ID)
+
+		time.Sleep(time.Second + time.Millisecond*500)
+
+		_, err = userSession.GetSuccessor(old.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		_, err = userSession.Rotate(old.ID, createUserSession(userTemp.ID), time.Now(), time.Second)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		inactives, err := items(userSession.GetByUserIDInactive(userTemp.ID, model.FirstPage(buffer)))
+		require.NoError(t, err)
+
+		qtOld := 0
+
+		for _, inactive := range inactives {
+			if inactive.ID == old.ID {
+				qtOld++
+			}
+		}
+
+		require.Equal(t, 1, qtOld)
+	})
+
+	t.Run("WithoutGrace", func(t *testing.T) {
+		t.Parallel()
+
+		old := createUserSession(userTemp.ID)
+		err := userSession.Create(old)
+		require.NoError(t, err)
+
+		_, err = userSession.Rotate(old.ID, createUserSession(userTemp.ID), time.Now(), 0)
+		require.NoError(t, err)
+
+		_, err = userSession.GetSuccessor(old.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		_, err = userSession.Rotate(old.ID, createUserSession(userTemp.ID), time.Now(), 0)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+
+	t.Run("SuccessorDeleted", func(t *testing.T) {
+		t.Parallel()
+
+		old := createUserSession(userTemp.ID)
+		err := userSession.Create(old)
+		require.NoError(t, err)
+
+		next := createUserSession(userTemp.ID)
+
+		successor, err := userSession.Rotate(old.ID, next, time.Now(), time.Second)
+		require.NoError(t, err)
+
+		_, err = userSession.Delete(successor.ID, time.Now())
+		require.NoError(t, err)
+
+		_, err = userSession.Rotate(old.ID, createUserSession(userTemp.ID), time.Now(), time.Second)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+
+		_, err = userSession.GetSuccessor(old.ID)
+		require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+	})
+}
+
+func TestUserSessionCreateWithLimit(t *testing.T) { //nolint:funlen
+	t.Parallel()
+
+	db := createTempDB(t, "data_user_session_limit")
+	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
+		Addr:     "localhost:6379",
+		Password: "redis",
+		DB:       0,
+	})
+	buffer := 250
+	limit := 3
+
+	user := data.NewUserSQL(db)
+	userSession := data.NewUserSessionRedis(redisClient, db, buffer)
+	err := userSession.ConsumeQueues(time.Second, buffer/2)
+	require.NoError(t, err)
+
+	go logErrors(t, userSession.Errors())
+
+	t.Run("Reject", func(t *testing.T) {
+		t.Parallel()
+
+		userTemp := createUser()
+		err := user.Create(userTemp)
+		require.NoError(t, err)
+
+		for i := 0; i < limit; i++ {
+			evicted, err := userSession.CreateWithLimit(createUserSession(userTemp.ID), limit, false)
+			require.NoError(t, err)
+			require.Empty(t, evicted)
+		}
+
+		evicted, err := userSession.CreateWithLimit(createUserSession(userTemp.ID), limit, false)
+		require.ErrorIs(t, err, errs.ErrUserSessionLimit)
+		require.Empty(t, evicted)
+
+		time.Sleep(time.Second * 3)
+
+		evicted, err = userSession.CreateWithLimit(createUserSession(userTemp.ID), limit, false)
+		require.NoError(t, err)
+		require.Empty(t, evicted)
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		t.Parallel()
+
+		userTemp := createUser()
+		err := user.Create(userTemp)
+		require.NoError(t, err)
+
+		sessions := make([]model.UserSession, 0, limit+2)
+
+		for i := 0; i < limit+2; i++ {
+			userSessionTemp := createUserSession(userTemp.ID)
+			sessions = append(sessions, userSessionTemp)
+
+			_, err := userSession.CreateWithLimit(userSessionTemp, limit, true)
+			require.NoError(t, err)
+		}
+
+		for _, evicted := range sessions[:2] {
+			_, err = userSession.GetByID(evicted.ID)
+			require.ErrorIs(t, err, errs.ErrUserSessionNotFound)
+		}
+
+		for _, active := range sessions[2:] {
+			_, err = userSession.GetByID(active.ID)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		userTemp := createUser()
+		err := user.Create(userTemp)
+		require.NoError(t, err)
+
+		qtRequests := 20
+		created := make([]bool, qtRequests)
+		wait := sync.WaitGroup{}
+
+		for i := 0; i < qtRequests; i++ {
+			wait.Add(1)
+
+			go func(i int) {
+				defer wait.Done()
+
+				_, err := userSession.CreateWithLimit(createUserSession(userTemp.ID), limit, false)
+				created[i] = err == nil
+			}(i)
+		}
+
+		wait.Wait()
+
+		qtCreated := 0
+
+		for _, ok := range created {
+			if ok {
+				qtCreated++
+			}
+		}
+
+		require.Equal(t, limit, qtCreated)
+	})
+
+	t.Run("DeleteFreesSlot", func(t *testing.T) {
+		t.Parallel()
+
+		userTemp := createUser()
+		err := user.Create(userTemp)
+		require.NoError(t, err)
+
+		first := createUserSession(userTemp.ID)
+
+		_, err = userSession.CreateWithLimit(first, 1, false)
+		require.NoError(t, err)
+
+		_, err = userSession.Delete(first.ID, time.Now())
+		require.NoError(t, err)
+
+		_, err = userSession.CreateWithLimit(createUserSession(userTemp.ID), 1, false)
+		require.NoError(t, err)
+	})
+}
+
 func TestUserSessionWrongDB(t *testing.T) {
 	t.Parallel()
 
@@ -346,19 +633,19 @@ func TestUserSessionWrongDB(t *testing.T) {
 		err = userSession.Create(userSessionTemp1)
 		require.NoError(t, err)
 
-		userSessions, err := userSession.GetAllActive(0, buffer)
+		userSessions, err := items(userSession.GetAllActive(model.FirstPage(buffer)))
 		require.ErrorContains(t, err, "no such host")
 		require.Equal(t, model.EmptyUserSessions, userSessions)
 
-		userSessions, err = userSession.GetAllInactive(0, buffer)
+		userSessions, err = items(userSession.GetAllInactive(model.FirstPage(buffer)))
 		require.ErrorContains(t, err, "no such host")
 		require.Equal(t, model.EmptyUserSessions, userSessions)
 
-		userSessions, err = userSession.GetByUserIDActive(model.NewID(), 0, buffer)
+		userSessions, err = items(userSession.GetByUserIDActive(model.NewID(), model.FirstPage(buffer)))
 		require.ErrorContains(t, err, "no such host")
 		require.Equal(t, model.EmptyUserSessions, userSessions)
 
-		userSessions, err = userSession.GetByUserIDInactive(model.NewID(), 0, buffer)
+		userSessions, err = items(userSession.GetByUserIDInactive(model.NewID(), model.FirstPage(buffer)))
 		require.ErrorContains(t, err, "no such host")
 		require.Equal(t, model.EmptyUserSessions, userSessions)
 
@@ -1,7 +1,11 @@
 package data_test
 
 import (
+	"encoding/base64"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,6 +29,7 @@ func createUserWithRoles(roles []string) model.User {
 		CreatedBy: model.NewID(),
 		DeletedAt: time.Time{},
 		DeletedBy: model.EmptyID,
+		Version:   1,
 	}
 }
 
@@ -54,6 +59,62 @@ func TestUserCreate(t *testing.T) {
 			require.NoError(t, err)
 		})
 	}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		t.Parallel()
+
+		tempUser := createUser()
+
+		err := user.Create(tempUser)
+		require.NoError(t, err)
+
+		duplicate := createUser()
+		duplicate.Username = tempUser.Username
+
+		err = user.Create(duplicate)
+		require.ErrorIs(t, err, errs.ErrUsernameAlreadyExist)
+
+		duplicate = createUser()
+		duplicate.Email = tempUser.Email
+
+		err = user.Create(duplicate)
+		require.ErrorIs(t, err, errs.ErrEmailAlreadyExist)
+
+		duplicate = createUser()
+		duplicate.Username = strings.ToUpper(tempUser.Username)
+
+		err = user.Create(duplicate)
+		require.ErrorIs(t, err, errs.ErrUsernameAlreadyExist)
+
+		duplicate = createUser()
+		duplicate.Email = strings.ToUpper(tempUser.Email)
+
+		err = user.Create(duplicate)
+		require.ErrorIs(t, err, errs.ErrEmailAlreadyExist)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		t.Parallel()
+
+		tempUser := createUser()
+
+		err := user.Create(tempUser)
+		require.NoError(t, err)
+
+		err = user.Delete(tempUser.ID, time.Now(), model.NewID())
+		require.NoError(t, err)
+
+		reused := createUser()
+		reused.Username = tempUser.Username
+		reused.Email = tempUser.Email
+
+		err = user.Create(reused)
+		require.NoError(t, err)
+
+		found, err := user.GetByUsername(tempUser.Username)
+		require.NoError(t, err)
+		checkUser(t, reused, found)
+	})
 }
 
 func checkUser(t *testing.T, expected, found model.User) {
@@ -72,6 +133,17 @@ func checkUser(t *testing.T, expected, found model.User) {
 	require.Equal(t, expected.DeletedBy, found.DeletedBy)
 }
 
+// checkListedUser checks users returned by list queries, which never load the
+// password hash.
+func checkListedUser(t *testing.T, expected, found model.User) {
+	t.Helper()
+
+	require.Empty(t, found.Password)
+
+	found.Password = expected.Password
+	checkUser(t, expected, found)
+}
+
 func TestGetBy(t *testing.T) {
 	t.Parallel()
 
@@ -117,6 +189,23 @@ func TestGetBy(t *testing.T) {
 		require.ErrorIs(t, err, errs.ErrUserNotFound)
 		require.Equal(t, found, model.EmptyUser)
 	})
+
+	t.Run("IgnoreCase", func(t *testing.T) {
+		t.Parallel()
+
+		tempUser := createUser()
+
+		err := user.Create(tempUser)
+		require.NoError(t, err)
+
+		found, err := user.GetByUsername(strings.ToUpper(tempUser.Username))
+		require.NoError(t, err)
+		checkUser(t, tempUser, found)
+
+		found, err = user.GetByEmail(strings.ToUpper(tempUser.Email))
+		require.NoError(t, err)
+		checkUser(t, tempUser, found)
+	})
 }
 
 func TestUserGetAll(t *testing.T) { //nolint:dupl
@@ -127,7 +216,7 @@ func TestUserGetAll(t *testing.T) { //nolint:dupl
 
 	user := data.NewUserSQL(createTempDB(t, "data_user_get_all"))
 
-	users, err := user.GetAll(0, qtUsers)
+	users, err := items(user.GetAll(model.ExcludeDeleted, model.FirstPage(qtUsers)))
 	require.NoError(t, err)
 	require.Equal(t, users, model.EmptyUsers)
 
@@ -139,7 +228,7 @@ func TestUserGetAll(t *testing.T) { //nolint:dupl
 		require.NoError(t, err)
 	}
 
-	users, err = user.GetAll(0, qtUsers)
+	users, err = items(user.GetAll(model.ExcludeDeleted, model.FirstPage(qtUsers)))
 	require.NoError(t, err)
 	require.Equal(t, len(users), qtUsers)
 
@@ -150,13 +239,21 @@ func TestUserGetAll(t *testing.T) { //nolint:dupl
 			return userID.ID == createdUser.ID
 		})
 		require.GreaterOrEqual(t, index, 0)
-		checkUser(t, createdUser, users[index])
+		checkListedUser(t, createdUser, users[index])
 
 		err := user.Delete(createdUser.ID, time.Now(), id)
 		require.NoError(t, err)
 	}
 
-	users, err = user.GetAll(0, qtUsers)
+	users, err = items(user.GetAll(model.ExcludeDeleted, model.FirstPage(qtUsers)))
+	require.NoError(t, err)
+	require.Equal(t, model.EmptyUsers, users)
+
+	users, err = items(user.GetAll(model.OnlyDeleted, model.FirstPage(qtUsers)))
+	require.NoError(t, err)
+	require.Equal(t, len(users), qtUsers)
+
+	users, err = items(user.GetAll(model.IncludeDeleted, model.FirstPage(qtUsers)))
 	require.NoError(t, err)
 	require.Equal(t, len(users), qtUsers)
 
@@ -169,7 +266,7 @@ func TestUserGetAll(t *testing.T) { //nolint:dupl
 		createdUser.DeletedBy = id
 		createdUser.DeletedAt = time.Now()
 
-		checkUser(t, createdUser, users[index])
+		checkListedUser(t, createdUser, users[index])
 	}
 }
 
@@ -181,7 +278,7 @@ func TestUserGetByRoles(t *testing.T) {
 
 	user := data.NewUserSQL(createTempDB(t, "data_user_get_by_roles"))
 
-	users, err := user.GetAll(0, qtUsers)
+	users, err := items(user.GetAll(model.ExcludeDeleted, model.FirstPage(qtUsers)))
 	require.NoError(t, err)
 	require.Equal(t, users, model.EmptyUsers)
 
@@ -200,7 +297,7 @@ func TestUserGetByRoles(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	users, err = user.GetByRoles(roles, 0, qtUsers)
+	users, err = items(user.GetByRoles(roles, model.ExcludeDeleted, model.FirstPage(qtUsers)))
 	require.NoError(t, err)
 	require.Equal(t, len(users), qtUsers)
 
@@ -211,13 +308,21 @@ func TestUserGetByRoles(t *testing.T) {
 			return userID.ID == createdUser.ID
 		})
 		require.GreaterOrEqual(t, index, 0)
-		checkUser(t, createdUser, users[index])
+		checkListedUser(t, createdUser, users[index])
 
 		err := user.Delete(createdUser.ID, time.Now(), id)
 		require.NoError(t, err)
 	}
 
-	users, err = user.GetByRoles(roles, 0, qtUsers)
+	users, err = items(user.GetByRoles(roles, model.ExcludeDeleted, model.FirstPage(qtUsers)))
+	require.NoError(t, err)
+	require.Equal(t, model.EmptyUsers, users)
+
+	users, err = items(user.GetByRoles(roles, model.OnlyDeleted, model.FirstPage(qtUsers)))
+	require.NoError(t, err)
+	require.Equal(t, len(users), qtUsers)
+
+	users, err = items(user.GetByRoles(roles, model.IncludeDeleted, model.FirstPage(qtUsers)))
 	require.NoError(t, err)
 	require.Equal(t, len(users), qtUsers)
 
@@ -230,7 +335,252 @@ func TestUserGetByRoles(t *testing.T) {
 		createdUser.DeletedBy = id
 		createdUser.DeletedAt = time.Now()
 
-		checkUser(t, createdUser, users[index])
+		checkListedUser(t, createdUser, users[index])
+	}
+}
+
+func TestUserSearch(t *testing.T) {
+	t.Parallel()
+
+	user := data.NewUserSQL(createTempDB(t, "data_user_search"))
+
+	creator := model.NewID()
+	now := time.Now().Truncate(time.Second)
+	inactive := false
+
+	names := []string{"alpha_one", "alphaxone", "beta one", "gamma 100%", "gamma two"}
+	createdUsers := make([]model.User, 0, len(names))
+
+	for i, name := range names {
+		tempUser := createUser()
+		tempUser.Name = name
+		tempUser.Username = "search" + strconv.Itoa(i)
+		tempUser.CreatedAt = now.Add(time.Duration(i) * time.Hour)
+
+		if i%2 == 0 {
+			tempUser.CreatedBy = creator
+			tempUser.Roles = []string{"search-role"}
+			tempUser.IsActive = false
+		}
+
+		createdUsers = append(createdUsers, tempUser)
+
+		err := user.Create(tempUser)
+		require.NoError(t, err)
+	}
+
+	ids := func(users []model.User) []model.ID {
+		found := make([]model.ID, 0, len(users))
+		for _, user := range users {
+			found = append(found, user.ID)
+		}
+
+		return found
+	}
+
+	tests := []struct {
+		name     string
+		filter   model.UserFilter
+		expected []int
+	}{
+		{
+			name:     "Default",
+			filter:   model.UserFilter{}, //nolint:exhaustruct
+			expected: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name:     "NameIgnoringCase",
+			filter:   model.UserFilter{Name: "ONE"}, //nolint:exhaustruct
+			expected: []int{0, 1, 2},
+		},
+		{
+			name:     "NameUnderscoreLiteral",
+			filter:   model.UserFilter{Name: "a_o"}, //nolint:exhaustruct
+			expected: []int{0},
+		},
+		{
+			name:     "NamePercentLiteral",
+			filter:   model.UserFilter{Name: "0%"}, //nolint:exhaustruct
+			expected: []int{3},
+		},
+		{
+			name:     "Username",
+			filter:   model.UserFilter{Username: "search4"}, //nolint:exhaustruct
+			expected: []int{4},
+		},
+		{
+			name:     "Email",
+			filter:   model.UserFilter{Email: createdUsers[1].Email}, //nolint:exhaustruct
+			expected: []int{1},
+		},
+		{
+			name:     "IsActive",
+			filter:   model.UserFilter{IsActive: &inactive}, //nolint:exhaustruct
+			expected: []int{0, 2, 4},
+		},
+		{
+			name:     "Role",
+			filter:   model.UserFilter{Role: "search-role"}, //nolint:exhaustruct
+			expected: []int{0, 2, 4},
+		},
+		{
+			name: "CreatedRange",
+			filter: model.UserFilter{ //nolint:exhaustruct
+				CreatedFrom: now.Add(time.Hour),
+				CreatedTo:   now.Add(3 * time.Hour),
+			},
+			expected: []int{1, 2, 3},
+		},
+		{
+			name:     "CreatedBy",
+			filter:   model.UserFilter{CreatedBy: creator}, //nolint:exhaustruct
+			expected: []int{0, 2, 4},
+		},
+		{
+			name: "Combined",
+			filter: model.UserFilter{ //nolint:exhaustruct
+				Name:      "gamma",
+				CreatedBy: creator,
+			},
+			expected: []int{4},
+		},
+		{
+			name: "SortNameDescending",
+			filter: model.UserFilter{ //nolint:exhaustruct
+				Sort: []model.UserSort{{Field: model.UserSortName, Descending: true}},
+			},
+			expected: []int{4, 3, 2, 1, 0},
+		},
+		{
+			name: "SortCreatedAtDescending",
+			filter: model.UserFilter{ //nolint:exhaustruct
+				Sort: []model.UserSort{{Field: model.UserSortCreatedAt, Descending: true}},
+			},
+			expected: []int{4, 3, 2, 1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			expected := make([]model.ID, 0, len(test.expected))
+			for _, index := range test.expected {
+				expected = append(expected, createdUsers[index].ID)
+			}
+
+			users, err := items(user.Search(test.filter, model.FirstPage(len(names))))
+			require.NoError(t, err)
+			require.Equal(t, expected, ids(users))
+
+			for _, found := range users {
+				index := slices.IndexFunc(createdUsers, func(created model.User) bool {
+					return created.ID == found.ID
+				})
+				checkListedUser(t, createdUsers[index], found)
+			}
+		})
+	}
+
+	t.Run("Paginate", func(t *testing.T) {
+		t.Parallel()
+
+		filter := model.UserFilter{ //nolint:exhaustruct
+			Sort: []model.UserSort{{Field: model.UserSortName, Descending: true}},
+		}
+		pagination := model.Pagination{Cursor: "", Qt: 2, Total: true}
+		found := []model.ID{}
+
+		for {
+			page, err := user.Search(filter, pagination)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Items), 2)
+			require.Equal(t, len(names), *page.Total)
+
+			found = append(found, ids(page.Items)...)
+
+			if page.NextCursor == "" {
+				break
+			}
+
+			pagination.Cursor = page.NextCursor
+		}
+
+		expected := []model.ID{}
+		for _, index := range []int{4, 3, 2, 1, 0} {
+			expected = append(expected, createdUsers[index].ID)
+		}
+
+		require.Equal(t, expected, found)
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		t.Parallel()
+
+		cursors := []string{
+			"invalid cursor",
+			base64.RawURLEncoding.EncodeToString([]byte(`["only one value"]`)),
+			base64.RawURLEncoding.EncodeToString([]byte(`["not a time", "not an id"]`)),
+		}
+
+		for _, cursor := range cursors {
+			pagination := model.Pagination{Cursor: cursor, Qt: len(names), Total: false}
+
+			page, err := user.Search(model.UserFilter{}, pagination) //nolint:exhaustruct
+			require.ErrorIs(t, err, errs.ErrInvalidCursor)
+			require.Equal(t, model.EmptyPage[model.User](), page)
+		}
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		t.Parallel()
+
+		filter := model.UserFilter{ //nolint:exhaustruct
+			Sort: []model.UserSort{{Field: "password", Descending: false}},
+		}
+
+		users, err := items(user.Search(filter, model.FirstPage(len(names))))
+		require.ErrorIs(t, err, errs.ErrInvalidSort)
+		require.Equal(t, model.EmptyUsers, users)
+	})
+}
+
+func TestUserGetSummaries(t *testing.T) {
+	t.Parallel()
+
+	qtUsers := 10
+
+	user := data.NewUserSQL(createTempDB(t, "data_user_get_summaries"))
+
+	createdUsers := make([]model.User, 0, qtUsers)
+	ids := make([]model.ID, 0, qtUsers+1)
+
+	for i := 0; i < qtUsers; i++ {
+		tempUser := createUser()
+
+		err := user.Create(tempUser)
+		require.NoError(t, err)
+
+		createdUsers = append(createdUsers, tempUser)
+		ids = append(ids, tempUser.ID)
+	}
+
+	err := user.Delete(ids[0], time.Now(), model.NewID())
+	require.NoError(t, err)
+
+	summaries, err := user.GetSummaries(append(ids, model.NewID()))
+	require.NoError(t, err)
+	require.Len(t, summaries, qtUsers)
+
+	for _, createdUser := range createdUsers {
+		index := slices.IndexFunc(summaries, func(summary model.UserSummary) bool {
+			return summary.ID == createdUser.ID
+		})
+		require.GreaterOrEqual(t, index, 0)
+		require.Equal(t, createdUser.Name, summaries[index].Name)
+		require.Equal(t, createdUser.Username, summaries[index].Username)
 	}
 }
 
@@ -275,6 +625,83 @@ func TestUserDelete(t *testing.T) {
 	})
 }
 
+func TestUserRestore(t *testing.T) {
+	t.Parallel()
+
+	user := data.NewUserSQL(createTempDB(t, "data_user_restore"))
+
+	tempUser := createUser()
+
+	err := user.Create(tempUser)
+	require.NoError(t, err)
+
+	err = user.Restore(tempUser.ID)
+	require.ErrorIs(t, err, errs.ErrUserNotFound)
+
+	err = user.Delete(tempUser.ID, time.Now(), model.NewID())
+	require.NoError(t, err)
+
+	err = user.Restore(tempUser.ID)
+	require.NoError(t, err)
+
+	found, err := user.GetByID(tempUser.ID)
+	require.NoError(t, err)
+	checkUser(t, tempUser, found)
+
+	err = user.Restore(tempUser.ID)
+	require.ErrorIs(t, err, errs.ErrUserNotFound)
+
+	err = user.Restore(model.NewID())
+	require.ErrorIs(t, err, errs.ErrUserNotFound)
+
+	t.Run("Reused", func(t *testing.T) {
+		t.Parallel()
+
+		for _, reuse := range []struct {
+			name string
+			err  error
+			set  func(reused *model.User, deleted model.User)
+		}{
+			{
+				name: "Username",
+				err:  errs.ErrUsernameAlreadyExist,
+				set:  func(reused *model.User, deleted model.User) { reused.Username = deleted.Username },
+			},
+			{
+				name: "Email",
+				err:  errs.ErrEmailAlreadyExist,
+				set:  func(reused *model.User, deleted model.User) { reused.Email = deleted.Email },
+			},
+		} {
+			reuse := reuse
+
+			t.Run(reuse.name, func(t *testing.T) {
+				t.Parallel()
+
+				deleted := createUser()
+
+				err := user.Create(deleted)
+				require.NoError(t, err)
+
+				err = user.Delete(deleted.ID, time.Now(), model.NewID())
+				require.NoError(t, err)
+
+				reused := createUser()
+				reuse.set(&reused, deleted)
+
+				err = user.Create(reused)
+				require.NoError(t, err)
+
+				err = user.Restore(deleted.ID)
+				require.ErrorIs(t, err, reuse.err)
+
+				_, err = user.GetByID(deleted.ID)
+				require.ErrorIs(t, err, errs.ErrUserNotFound)
+			})
+		}
+	})
+}
+
 func TestUserUpdate(t *testing.T) {
 	t.Parallel()
 
@@ -333,8 +760,157 @@ func TestUserUpdate(t *testing.T) {
 		t.Parallel()
 
 		err := user.Update(createUser())
+		require.ErrorIs(t, err, errs.ErrUserVersionMismatch)
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		t.Parallel()
+
+		tempUser := createUser()
+
+		err := user.Create(tempUser)
+		require.NoError(t, err)
+
+		qtUpdates := 20
+		results := make([]error, qtUpdates)
+
+		var wait sync.WaitGroup
+
+		for i := range results {
+			wait.Add(1)
+
+			go func(i int) {
+				defer wait.Done()
+
+				update := tempUser
+				update.Name = gofakeit.Name()
+
+				results[i] = user.Update(update)
+			}(i)
+		}
+
+		wait.Wait()
+
+		updated := 0
+
+		for _, err := range results {
+			if err == nil {
+				updated++
+
+				continue
+			}
+
+			require.ErrorIs(t, err, errs.ErrUserVersionMismatch)
+		}
+
+		require.Equal(t, 1, updated)
+
+		found, err := user.GetByID(tempUser.ID)
+		require.NoError(t, err)
+		require.Equal(t, tempUser.Version+1, found.Version)
+
+		err = user.Update(tempUser)
+		require.ErrorIs(t, err, errs.ErrUserVersionMismatch)
+
+		err = user.Update(found)
 		require.NoError(t, err)
 	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		t.Parallel()
+
+		tempUser1, tempUser2 := createUser(), createUser()
+
+		err := user.Create(tempUser1)
+		require.NoError(t, err)
+
+		err = user.Create(tempUser2)
+		require.NoError(t, err)
+
+		duplicate := tempUser1
+		duplicate.Username = tempUser2.Username
+
+		err = user.Update(duplicate)
+		require.ErrorIs(t, err, errs.ErrUsernameAlreadyExist)
+
+		duplicate = tempUser1
+		duplicate.Email = tempUser2.Email
+
+		err = user.Update(duplicate)
+		require.ErrorIs(t, err, errs.ErrEmailAlreadyExist)
+
+		found, err := user.GetByID(tempUser1.ID)
+		require.NoError(t, err)
+		checkUser(t, tempUser1, found)
+	})
+}
+
+func TestUserGetPasswords(t *testing.T) {
+	t.Parallel()
+
+	user := data.NewUserSQL(createTempDB(t, "data_user_passwords"))
+
+	tempUser := createUser()
+
+	err := user.Create(tempUser)
+	require.NoError(t, err)
+
+	qtPasswords := 10
+	passwords := make([]string, 0, qtPasswords)
+
+	for i := 0; i < qtPasswords; i++ {
+		passwords = append(passwords, tempUser.Password)
+
+		tempUser.Password = gofakeit.Password(true, true, true, true, true, 50)
+
+		err = user.Update(tempUser)
+		require.NoError(t, err)
+
+		tempUser.Version++
+	}
+
+	err = user.Update(tempUser)
+	require.NoError(t, err)
+
+	found, err := user.GetPasswords(tempUser.ID, qtPasswords*2)
+	require.NoError(t, err)
+	require.Len(t, found, qtPasswords)
+
+	for i, password := range found {
+		require.Equal(t, passwords[qtPasswords-1-i], password)
+	}
+
+	found, err = user.GetPasswords(tempUser.ID, qtPasswords/2)
+	require.NoError(t, err)
+	require.Len(t, found, qtPasswords/2)
+
+	found, err = user.GetPasswords(model.NewID(), qtPasswords)
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
+
+func TestUserUpdatePassword(t *testing.T) {
+	t.Parallel()
+
+	user := data.NewUserSQL(createTempDB(t, "data_user_update_password"))
+
+	tempUser := createUser()
+
+	err := user.Create(tempUser)
+	require.NoError(t, err)
+
+	tempUser.Password = gofakeit.Password(true, true, true, true, true, 50)
+
+	err = user.UpdatePassword(tempUser.ID, tempUser.Password)
+	require.NoError(t, err)
+
+	found, err := user.GetByID(tempUser.ID)
+	require.NoError(t, err)
+	checkUser(t, tempUser, found)
+
+	passwords, err := user.GetPasswords(tempUser.ID, 10)
+	require.NoError(t, err)
+	require.Empty(t, passwords)
 }
 
 func TestUserWrongDB(t *testing.T) {
@@ -363,11 +939,31 @@ func TestUserWrongDB(t *testing.T) {
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, found, model.EmptyUser)
 
-	roles, err := user.GetAll(0, 100)
+	roles, err := items(user.GetAll(model.ExcludeDeleted, model.FirstPage(100)))
+	require.ErrorContains(t, err, "no such host")
+	require.Equal(t, roles, model.EmptyUsers)
+
+	roles, err = items(user.GetByRoles([]string{gofakeit.Name()}, model.ExcludeDeleted, model.FirstPage(100)))
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, roles, model.EmptyUsers)
 
-	roles, err = user.GetByRoles([]string{gofakeit.Name()}, 0, 100)
+	filter := model.UserFilter{Name: gofakeit.Name()} //nolint:exhaustruct
+
+	roles, err = items(user.Search(filter, model.FirstPage(100)))
 	require.ErrorContains(t, err, "no such host")
 	require.Equal(t, roles, model.EmptyUsers)
+
+	err = user.UpdatePassword(model.NewID(), gofakeit.Password(true, true, true, true, true, 50))
+	require.ErrorContains(t, err, "no such host")
+
+	passwords, err := user.GetPasswords(model.NewID(), 100)
+	require.ErrorContains(t, err, "no such host")
+	require.Empty(t, passwords)
+
+	summaries, err := user.GetSummaries([]model.ID{model.NewID()})
+	require.ErrorContains(t, err, "no such host")
+	require.Empty(t, summaries)
+
+	err = user.Restore(model.NewID())
+	require.ErrorContains(t, err, "no such host")
 }
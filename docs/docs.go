@@ -16,14 +16,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/role": {
+        "/me": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get all roles",
+                "description": "Get the user of the current session.",
                 "consumes": [
                     "application/json"
                 ],
@@ -31,31 +31,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "role"
-                ],
-                "summary": "Get roles",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "result page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "quantity roles per page",
-                        "name": "qt",
-                        "in": "query"
-                    }
+                    "me"
                 ],
+                "summary": "Get current user",
                 "responses": {
                     "200": {
-                        "description": "all roles",
+                        "description": "current user",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Role"
-                            }
+                            "$ref": "#/definitions/model.UserResponse"
                         }
                     },
                     "401": {
@@ -72,13 +55,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "put": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Create a role.",
+                "description": "Update the name, username and email of the user of the current session.",
                 "consumes": [
                     "application/json"
                 ],
@@ -86,47 +69,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "role"
+                    "me"
                 ],
-                "summary": "Create role",
+                "summary": "Update current user",
                 "parameters": [
                     {
-                        "description": "role params",
-                        "name": "role",
+                        "description": "user params",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.RolePartial"
+                            "$ref": "#/definitions/model.UserSelfUpdate"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "create role successfully",
+                    "200": {
+                        "description": "update user successfully",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "400": {
-                        "description": "an invalid role param was sent",
+                        "description": "an invalid user param was sent",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "401": {
-                        "description": "user session has expired",
-                        "schema": {
-                            "$ref": "#/definitions/server.sent"
-                        }
-                    },
-                    "403": {
-                        "description": "current user is not admin",
+                        "description": "user session has expired or must be reauthenticated",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "409": {
-                        "description": "role already exist",
+                        "description": "username/email already exist or user was modified concurrently",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -138,16 +115,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/role/{name}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get role by name.",
+                "description": "Delete the user of the current session, revoking all their sessions and tokens.",
                 "consumes": [
                     "application/json"
                 ],
@@ -155,33 +130,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "role"
-                ],
-                "summary": "Get role",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "role name",
-                        "name": "name",
-                        "in": "path",
-                        "required": true
-                    }
+                    "me"
                 ],
+                "summary": "Delete current user",
                 "responses": {
                     "200": {
-                        "description": "role return",
-                        "schema": {
-                            "$ref": "#/definitions/model.Role"
-                        }
-                    },
-                    "401": {
-                        "description": "user session has expired",
+                        "description": "user deleted",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
-                    "404": {
-                        "description": "role does not exist",
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -193,14 +153,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/me/password": {
+            "put": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Delete a role.",
+                "description": "Change the password of the user of the current session after checking the current password.",
                 "consumes": [
                     "application/json"
                 ],
@@ -208,39 +170,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "role"
+                    "me"
                 ],
-                "summary": "Delete role",
+                "summary": "Change current user password",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "role name",
-                        "name": "name",
-                        "in": "path",
-                        "required": true
+                        "description": "password params",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PasswordUpdate"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "role deleted",
+                        "description": "password changed successfully",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
-                    "401": {
-                        "description": "user session has expired",
+                    "400": {
+                        "description": "an invalid password param was sent",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
-                    "403": {
-                        "description": "current user is not admin",
+                    "401": {
+                        "description": "user session has expired",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
-                    "404": {
-                        "description": "role does not exist",
+                    "409": {
+                        "description": "user was modified concurrently",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -250,18 +214,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
+                    },
+                    "503": {
+                        "description": "too many password operations",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
                     }
                 }
             }
         },
-        "/session": {
-            "put": {
+        "/me/session": {
+            "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Refresh a user session and set in the response header.",
+                "description": "Get the sessions of the user of the current session, sorted by creation. Sessions impersonating a user are sessions of the impersonator.",
                 "consumes": [
                     "application/json"
                 ],
@@ -269,68 +239,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "session"
+                    "me"
                 ],
-                "summary": "Refresh session",
-                "responses": {
-                    "200": {
-                        "description": "user session refreshed successfully",
-                        "schema": {
-                            "$ref": "#/definitions/server.sent"
-                        }
+                "summary": "Get current user sessions",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "get the inactive sessions instead of the active ones",
+                        "name": "inactive",
+                        "in": "query"
                     },
-                    "401": {
-                        "description": "user session has expired",
-                        "schema": {
-                            "$ref": "#/definitions/server.sent"
-                        }
+                    {
+                        "type": "string",
+                        "description": "cursor of the page, from nextCursor",
+                        "name": "cursor",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/server.sent"
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Create a user session and set in the response header.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "session"
-                ],
-                "summary": "Create session",
-                "parameters": [
                     {
-                        "description": "user params",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.UserSessionPartial"
-                        }
+                        "type": "string",
+                        "description": "quantity sessions per page",
+                        "name": "qt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also count all the sessions",
+                        "name": "total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "session created successfully",
+                    "200": {
+                        "description": "current user sessions",
                         "schema": {
-                            "$ref": "#/definitions/server.sent"
+                            "$ref": "#/definitions/model.Page-model_UserSessionResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "link to the next page"
+                            }
                         }
                     },
                     "400": {
-                        "description": "an invalid user param was sent",
+                        "description": "an invalid cursor was sent",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
-                    "404": {
-                        "description": "user does not exist",
+                    "401": {
+                        "description": "user session has expired",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -344,14 +302,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user": {
+        "/role": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get all user",
+                "description": "Get all roles, sorted by name.",
                 "consumes": [
                     "application/json"
                 ],
@@ -359,39 +317,78 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "role"
                 ],
-                "summary": "Get users",
+                "summary": "Get roles",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "result page number",
-                        "name": "page",
+                        "description": "cursor of the page, from nextCursor",
+                        "name": "cursor",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "quantity user per page",
+                        "description": "quantity roles per page",
                         "name": "qt",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also count all the roles",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also get the deleted roles",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only get the deleted roles",
+                        "name": "onlyDeleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "all roles",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Page-model_Role"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "link to the next page"
                             }
                         }
                     },
+                    "400": {
+                        "description": "an invalid field, deleted or cursor param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
                     "401": {
                         "description": "user session has expired",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
+                    "403": {
+                        "description": "deleted records were requested and the current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
                     "500": {
                         "description": "internal server error",
                         "schema": {
@@ -406,7 +403,7 @@ const docTemplate = `{
                         "BasicAuth": []
                     }
                 ],
-                "description": "Create a user.",
+                "description": "Create a role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -414,35 +411,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "role"
                 ],
-                "summary": "Create user",
+                "summary": "Create role",
                 "parameters": [
                     {
-                        "description": "user params",
-                        "name": "user",
+                        "description": "role params",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.UserPartial"
+                            "$ref": "#/definitions/model.RolePartial"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "create user successfully",
+                        "description": "create role successfully",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "400": {
-                        "description": "an invalid user param was sent",
+                        "description": "an invalid role param was sent",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "401": {
-                        "description": "user session has expired",
+                        "description": "user session has expired or must be reauthenticated",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -454,7 +451,7 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "username/email already exist",
+                        "description": "role already exist",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -468,14 +465,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/roles": {
+        "/role/{name}": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get users by roles.",
+                "description": "Get role by name.",
                 "consumes": [
                     "application/json"
                 ],
@@ -483,46 +480,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "role"
                 ],
-                "summary": "Get users by roles",
+                "summary": "Get role",
                 "parameters": [
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "roles",
-                        "name": "roles",
-                        "in": "query",
-                        "required": true
-                    },
                     {
                         "type": "string",
-                        "description": "result page number",
-                        "name": "page",
-                        "in": "query"
+                        "description": "role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "quantity user per page",
-                        "name": "qt",
+                        "description": "comma separated fields to return",
+                        "name": "fields",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "user return",
+                        "description": "role return",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.User"
-                            }
+                            "$ref": "#/definitions/model.Role"
                         }
                     },
                     "400": {
-                        "description": "an invalid role param was sent",
+                        "description": "an invalid field param was sent",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -534,7 +518,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "user does not exist",
+                        "description": "role does not exist",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -546,16 +530,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Get user by id.",
+                "description": "Delete a role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -563,33 +545,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "role"
                 ],
-                "summary": "Get user by id",
+                "summary": "Delete role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user id",
-                        "name": "id",
+                        "description": "role name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "user return",
+                        "description": "role deleted",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/server.sent"
                         }
                     },
                     "401": {
-                        "description": "user session has expired",
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not admin",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "404": {
-                        "description": "user does not exist",
+                        "description": "role does not exist",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -601,14 +589,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/role/{name}/restore": {
+            "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Update a user informations.",
+                "description": "Restore a deleted role, it is not given back to the users that had it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -616,36 +606,79 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "role"
                 ],
-                "summary": "Update user",
+                "summary": "Restore role",
                 "parameters": [
-                    {
-                        "description": "user params",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.UserUpdate"
-                        }
-                    },
                     {
                         "type": "string",
-                        "description": "user id",
-                        "name": "id",
+                        "description": "role name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "update user successfully",
+                        "description": "role restored",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
-                    "400": {
-                        "description": "an invalid user param was sent",
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "role does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "409": {
+                        "description": "role is not deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/session": {
+            "put": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Refresh a user session and set in the response header. The session is only replaced when it is close to expiring.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Refresh session",
+                "responses": {
+                    "200": {
+                        "description": "user session refreshed successfully",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -657,7 +690,57 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "current user is not admin",
+                        "description": "missing or invalid csrf token",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a user session and set in the response header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Create session",
+                "parameters": [
+                    {
+                        "description": "user params",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UserSessionPartial"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "session created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid user param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "maximum number of user sessions reached",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -668,8 +751,60 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.sent"
                         }
                     },
-                    "409": {
-                        "description": "username/email already exist",
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "503": {
+                        "description": "too many password operations",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/session/impersonate": {
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Delete the impersonated session, the impersonator must go back to their own session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Stop impersonation",
+                "responses": {
+                    "200": {
+                        "description": "impersonation stopped successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "400": {
+                        "description": "user session is not impersonating",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "operation requires a user session",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -681,14 +816,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/session/impersonate/{userId}": {
+            "post": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "Delete a user.",
+                "description": "Create a session of the user for the current user, who must have the impersonator role, and set in the response header. Impersonated sessions can not impersonate other users.",
                 "consumes": [
                     "application/json"
                 ],
@@ -696,33 +833,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "session"
                 ],
-                "summary": "Delete user",
+                "summary": "Impersonate user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user id",
-                        "name": "id",
+                        "description": "user ID",
+                        "name": "userId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "user deleted",
+                    "201": {
+                        "description": "impersonation started successfully",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "401": {
-                        "description": "user session has expired",
+                        "description": "user session has expired or must be reauthenticated",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
                     },
                     "403": {
-                        "description": "current user is not admin",
+                        "description": "current user is not allowed to impersonate or not using a session",
                         "schema": {
                             "$ref": "#/definitions/server.sent"
                         }
@@ -741,71 +878,1472 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.Role": {
-            "type": "object",
-            "properties": {
-                "createdAt": {
-                    "type": "string"
-                },
-                "createdBy": {
-                    "type": "string"
-                },
-                "deletedAt": {
-                    "type": "string"
-                },
-                "deletedBy": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "model.RolePartial": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
-                "name": {
-                    "type": "string",
-                    "maxLength": 255
-                }
-            }
         },
-        "model.User": {
-            "type": "object",
-            "properties": {
-                "createdAt": {
-                    "type": "string"
-                },
-                "createdBy": {
-                    "type": "string"
-                },
-                "deletedAt": {
-                    "type": "string"
+        "/session/password": {
+            "put": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Change the password of the session user and set a new session in the response header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "password params",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PasswordUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "password changed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid password param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "operation requires a user session",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "409": {
+                        "description": "user was modified concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "503": {
+                        "description": "too many password operations",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/session/reauthenticate": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Check the password again, allowing operations that require a recent authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Reauthenticate session",
+                "parameters": [
+                    {
+                        "description": "password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Reauthentication"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "session reauthenticated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid password was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "operation requires a user session",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "503": {
+                        "description": "too many password operations",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get all user, filtered and sorted by the query params. Without sort the users are sorted by creation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "cursor of the page, from nextCursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "quantity user per page",
+                        "name": "qt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also count all the users",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated expansions: roles, createdBy",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name substring, ignoring case",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "username substring, ignoring case",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "email substring, ignoring case",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only active or inactive users",
+                        "name": "isActive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only users with the role",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "users created at or after, RFC 3339",
+                        "name": "createdFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "users created at or before, RFC 3339",
+                        "name": "createdTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user creator",
+                        "name": "createdBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated sort fields, prefixed with '-' for descending: name, username, email, createdAt, passwordChangedAt",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also get the deleted users",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only get the deleted users",
+                        "name": "onlyDeleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "all users",
+                        "schema": {
+                            "$ref": "#/definitions/model.Page-model_UserResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "link to the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid field, expand, filter, sort or cursor param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "deleted records were requested and the current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Create a user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "description": "user params",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UserPartial"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "create user successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid user param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "409": {
+                        "description": "username/email already exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "503": {
+                        "description": "too many password operations",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/import": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Create a user with a password hash from another system. Hashes with parameters above the limits are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Import user",
+                "parameters": [
+                    {
+                        "description": "user params",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UserImport"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "import user successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid user param or password hash was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "409": {
+                        "description": "username/email already exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/roles": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get users by roles, sorted by creation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get users by roles",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "roles",
+                        "name": "roles",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor of the page, from nextCursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "quantity user per page",
+                        "name": "qt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also count all the users",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated expansions: roles, createdBy",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also get the deleted users",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only get the deleted users",
+                        "name": "onlyDeleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user return",
+                        "schema": {
+                            "$ref": "#/definitions/model.Page-model_UserResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "link to the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid role, field, expand, deleted or cursor param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "deleted records were requested and the current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get user by id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated expansions: roles, createdBy",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user return",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "user version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid field or expand param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Update a user informations, only when the user is still at the If-Match version. With If-Match \"*\" the update is applied over concurrent changes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "description": "user params",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UserUpdate"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "version of the user, from the ETag",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "update user successfully",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid user param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "409": {
+                        "description": "username/email already exist or, with If-Match \"*\", user was modified concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "412": {
+                        "description": "user was modified since the If-Match version",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is required",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "503": {
+                        "description": "too many password operations",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Delete a user, revoking all their sessions and tokens.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user deleted",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Restore a deleted user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Restore user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user restored",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "409": {
+                        "description": "user is not deleted or username/email already exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/session": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get the sessions of a user, sorted by creation, only for the user and admins. Sessions impersonating a user are sessions of the impersonator.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Get user sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "get the inactive sessions instead of the active ones",
+                        "name": "inactive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor of the page, from nextCursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "quantity sessions per page",
+                        "name": "qt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also count all the sessions",
+                        "name": "total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user sessions",
+                        "schema": {
+                            "$ref": "#/definitions/model.Page-model_UserSessionResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "link to the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid cursor was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not the user nor admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/token": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get the tokens of a user, without their secrets.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Get user tokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor of the page, from nextCursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "quantity tokens per page",
+                        "name": "qt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also count all the tokens",
+                        "name": "total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user tokens",
+                        "schema": {
+                            "$ref": "#/definitions/model.Page-model_Token"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "link to the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid cursor was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not the user nor admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Create a token with a subset of the user roles. The token is sent in the Authorization header as \"Bearer \u003ctoken\u003e\" instead of a session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Create token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "token params",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TokenPartial"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "token created, the secret is only shown once",
+                        "schema": {
+                            "$ref": "#/definitions/model.TokenCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid token param was sent",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired or must be reauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "tokens, impersonated sessions and other users can not create tokens",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "user does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/token/{tokenId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Revoke a token of the user, it can not be used anymore.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Revoke token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "token id",
+                        "name": "tokenId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "token revoked",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "401": {
+                        "description": "user session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "403": {
+                        "description": "current user is not the user nor admin",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "404": {
+                        "description": "token does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.sent"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.KeycloakCredential": {
+            "type": "object",
+            "required": [
+                "credentialData",
+                "secretData"
+            ],
+            "properties": {
+                "credentialData": {
+                    "type": "string"
+                },
+                "secretData": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Page-model_Role": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Role"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Page-model_Token": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Token"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Page-model_UserResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.UserResponse"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Page-model_UserSessionResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.UserSessionResponse"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.PasswordUpdate": {
+            "type": "object",
+            "required": [
+                "currentPassword",
+                "newPassword"
+            ],
+            "properties": {
+                "currentPassword": {
+                    "type": "string"
+                },
+                "newPassword": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "model.Reauthentication": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Role": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
                 },
                 "deletedBy": {
                     "type": "string"
                 },
-                "email": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.RolePartial": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "model.Token": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "deletedBy": {
+                    "type": "string"
+                },
+                "expires": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "isActive": {
-                    "type": "boolean"
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TokenCreated": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "deletedBy": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
-                "password": {
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TokenPartial": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expires": {
                     "type": "string"
                 },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.UserImport": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "keycloak": {
+                    "$ref": "#/definitions/model.KeycloakCredential"
+                },
+                "mustChangePassword": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "passwordHash": {
+                    "type": "string",
+                    "maxLength": 255
+                },
                 "roles": {
                     "type": "array",
                     "items": {
@@ -813,7 +2351,8 @@ const docTemplate = `{
                     }
                 },
                 "username": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 }
             }
         },
@@ -830,6 +2369,9 @@ const docTemplate = `{
                     "type": "string",
                     "maxLength": 255
                 },
+                "mustChangePassword": {
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string",
                     "maxLength": 255
@@ -850,23 +2392,144 @@ const docTemplate = `{
                 }
             }
         },
+        "model.UserResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "deletedBy": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isActive": {
+                    "type": "boolean"
+                },
+                "mustChangePassword": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passwordChangedAt": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "username": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.UserSelfUpdate": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
         "model.UserSessionPartial": {
             "type": "object",
             "required": [
                 "password"
             ],
             "properties": {
+                "deviceName": {
+                    "type": "string",
+                    "maxLength": 255
+                },
                 "email": {
                     "type": "string"
                 },
                 "password": {
                     "type": "string"
                 },
+                "rememberMe": {
+                    "type": "boolean"
+                },
                 "username": {
                     "type": "string"
                 }
             }
         },
+        "model.UserSessionResponse": {
+            "type": "object",
+            "properties": {
+                "authenticatedAt": {
+                    "type": "string"
+                },
+                "authenticationMethod": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "deviceName": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "handle": {
+                    "type": "string"
+                },
+                "impersonatorId": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "originalCreatedAt": {
+                    "type": "string"
+                },
+                "rememberMe": {
+                    "type": "boolean"
+                },
+                "restricted": {
+                    "type": "boolean"
+                },
+                "userAgent": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
         "model.UserUpdate": {
             "type": "object",
             "properties": {
@@ -877,6 +2540,9 @@ const docTemplate = `{
                 "isActive": {
                     "type": "boolean"
                 },
+                "mustChangePassword": {
+                    "type": "boolean"
+                },
                 "name": {
                     "type": "string",
                     "maxLength": 255
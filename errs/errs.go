@@ -12,4 +12,35 @@ var (
 	ErrRoleAlreadyExist     = errors.New("role already exist")
 	ErrUserSessionNotFound  = errors.New("user session not found")
 	ErrPasswordDoesNotMatch = errors.New("password does not match")
+	ErrPasswordReused       = errors.New("password was used recently")
+	ErrPasswordExpired      = errors.New("password expired, it must be changed")
+	ErrHashUnknown          = errors.New("unknown password hash format")
+	ErrHashParameters       = errors.New("password hash parameters are above the allowed limits")
+	ErrPasswordPoolFull     = errors.New("too many password operations, try again later")
+	ErrCSRFTokenInvalid     = errors.New("missing or invalid csrf token")
+	ErrUserSessionLimit     = errors.New("maximum number of user sessions reached")
+	ErrReauthentication     = errors.New("recent authentication required, reauthenticate the session")
+	ErrImpersonationDenied  = errors.New("current user is not allowed to impersonate users")
+	ErrNestedImpersonation  = errors.New("an impersonated session can not impersonate other users")
+	ErrImpersonatedSession  = errors.New("operation not allowed while impersonating a user")
+	ErrUserNotAdmin         = errors.New("current user is not admin")
+	ErrTokenNotOwner        = errors.New("tokens can only be created by their own user")
+	ErrNotImpersonating     = errors.New("user session is not impersonating a user")
+	ErrTokenNotFound        = errors.New("token not found")
+	ErrTokenRoles           = errors.New("token roles must be a subset of the user roles")
+	ErrTokenExpired         = errors.New("token expiration must be in the future")
+	ErrSessionRequired      = errors.New("operation requires a user session, tokens are not allowed")
+	ErrInvalidField         = errors.New("invalid field")
+	ErrInvalidExpand        = errors.New("invalid expand, it must be roles or createdBy")
+	ErrInvalidFilter        = errors.New("invalid filter")
+	ErrInvalidSort          = errors.New("invalid sort field")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrUserNotDeleted       = errors.New("user is not deleted")
+	ErrRoleNotDeleted       = errors.New("role is not deleted")
+	ErrUserVersionMismatch  = errors.New("user was modified, its version does not match")
+	ErrVersionRequired      = errors.New("If-Match header with the version is required")
+	ErrPasswordPanic        = errors.New("password operation failed unexpectedly")
+	ErrPoolConfiguration    = errors.New(
+		"password pool concurrency must be greater than 0 and queue not negative",
+	)
 )
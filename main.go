@@ -1,9 +1,13 @@
 package main
 
 import (
+	"crypto/rand"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -11,6 +15,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
 	"github.com/thiago-felipe-99/autenticacao/core"
 	"github.com/thiago-felipe-99/autenticacao/data"
@@ -19,6 +24,8 @@ import (
 	"github.com/thiago-felipe-99/autenticacao/server"
 )
 
+var errInvalidRoleLimit = errors.New("invalid session limit per role")
+
 func createFirst(configurations *configurations, cores *core.Cores) error {
 	roleAdmin := model.RolePartial{Name: configurations.Role.Name}
 
@@ -39,6 +46,8 @@ func createFirst(configurations *configurations, cores *core.Cores) error {
 		Email:    configurations.User.Email,
 		Password: configurations.User.Password,
 		Roles:    []string{roleAdmin.Name},
+
+		MustChangePassword: true,
 	}
 
 	_, err = cores.User.Create(model.EmptyID, userAdmin)
@@ -57,6 +66,74 @@ func createFirst(configurations *configurations, cores *core.Cores) error {
 	return nil
 }
 
+func passwordHash(configurations *configurations) core.PasswordHash {
+	hash := core.DefaultPasswordHash()
+
+	hash.Algorithm = configurations.Hash.Algorithm
+	hash.Argon2id.Memory = configurations.Hash.Argon2idMemory
+	hash.Argon2id.Iterations = configurations.Hash.Argon2idIterations
+	hash.Argon2id.Parallelism = configurations.Hash.Argon2idParallelism
+	hash.BcryptCost = configurations.Hash.BcryptCost
+	hash.ScryptN = configurations.Hash.ScryptN
+	hash.ScryptR = configurations.Hash.ScryptR
+	hash.ScryptP = configurations.Hash.ScryptP
+
+	return hash
+}
+
+// sessionLimit builds the session limit, reading the per role limits in the
+// format "role:limit,role:limit".
+func sessionLimit(configurations *configurations) (core.SessionLimit, error) {
+	roles := map[string]int{}
+
+	for _, roleLimit := range strings.Split(configurations.Session.MaxPerRole, ",") {
+		if strings.TrimSpace(roleLimit) == "" {
+			continue
+		}
+
+		role, limitRaw, found := strings.Cut(roleLimit, ":")
+
+		limit, err := strconv.Atoi(strings.TrimSpace(limitRaw))
+		if !found || err != nil || limit < 0 {
+			return core.SessionLimit{}, fmt.Errorf("%w: %s", errInvalidRoleLimit, roleLimit)
+		}
+
+		roles[strings.TrimSpace(role)] = limit
+	}
+
+	return core.SessionLimit{
+		Default: configurations.Session.MaxPerUser,
+		Roles:   roles,
+		Evict:   configurations.Session.LimitPolicy == "evict",
+	}, nil
+}
+
+// sessionCookie builds the cookie configuration, using a random CSRF secret
+// when none is configured, which invalidates CSRF tokens on every restart.
+func sessionCookie(configurations *configurations) (server.Cookie, error) {
+	secret := []byte(configurations.Cookie.CSRFSecret)
+
+	if len(secret) == 0 {
+		secret = make([]byte, 32) //nolint:gomnd
+
+		_, err := rand.Read(secret)
+		if err != nil {
+			return server.Cookie{}, fmt.Errorf("error creating csrf secret: %w", err)
+		}
+	}
+
+	return server.Cookie{
+		Enabled:    configurations.Cookie.Enabled,
+		Name:       configurations.Cookie.Name,
+		CSRFName:   configurations.Cookie.CSRFName,
+		Domain:     configurations.Cookie.Domain,
+		Path:       configurations.Cookie.Path,
+		Secure:     configurations.Cookie.Secure,
+		SameSite:   configurations.Cookie.SameSite,
+		CSRFSecret: secret,
+	}, nil
+}
+
 func noError(err error, msg string) {
 	if err != nil {
 		log.Panicf("[ERROR] - %s: %s", msg, err)
@@ -74,6 +151,14 @@ func noError(err error, msg string) {
 //	@in							header
 //	@name						Session
 func main() {
+	checkCollisions := flag.Bool(
+		"check-collisions",
+		false,
+		"report users with the same normalized username or email and exit",
+	)
+
+	flag.Parse()
+
 	validate := model.Validate()
 
 	configurations, err := getConfigurations(validate)
@@ -91,9 +176,21 @@ func main() {
 		url += "?sslmode=disable"
 	}
 
+	db, err := sqlx.Connect("postgres", url)
+	noError(err, "Error opening database")
+
 	migrations, err := migrate.New("file://data/migrations", url)
 	noError(err, "Error starting migrations")
 
+	if *checkCollisions || needsCollisionsCheck(migrations) {
+		err = checkUsersCollisions(db)
+		noError(err, "Error checking users before migrating")
+
+		if *checkCollisions {
+			return
+		}
+	}
+
 	err = migrations.Up()
 	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		noError(err, "Error migrating database")
@@ -103,9 +200,6 @@ func main() {
 	noError(err, "Error closing migration")
 	noError(sourcerr, "Error in migration source")
 
-	db, err := sqlx.Connect("postgres", url)
-	noError(err, "Error opening database")
-
 	redisClient := redis.NewClient(&redis.Options{ //nolint:exhaustruct
 		Addr:     fmt.Sprintf("%s:%d", configurations.Redis.Host, configurations.Redis.Port),
 		Password: configurations.Redis.Password,
@@ -120,12 +214,47 @@ func main() {
 	data, err := data.NewDataSQLRedis(db, redisClient, expires, 2000, 1000) //nolint:gomnd
 	noError(err, "Error starting data")
 
-	cores := core.NewCore(data, validate, true, time.Hour)
+	passwordPool, err := core.NewPasswordPool(
+		configurations.Hash.PoolConcurrency,
+		configurations.Hash.PoolQueue,
+		prometheus.DefaultRegisterer,
+	)
+	noError(err, "Error starting password pool")
+
+	limit, err := sessionLimit(configurations)
+	noError(err, "Error reading session limit")
+
+	cores := core.NewCore(
+		data,
+		validate,
+		passwordHash(configurations),
+		passwordPool,
+		configurations.Password.History,
+		configurations.Password.MaxAge,
+		configurations.Session.IdleTimeout,
+		configurations.Session.MaxLifetime,
+		configurations.Session.RememberIdleTimeout,
+		configurations.Session.RememberMaxLifetime,
+		configurations.Session.RefreshThreshold,
+		configurations.Session.RefreshGrace,
+		limit,
+	)
 
 	err = createFirst(configurations, cores)
 	noError(err, "Erro creating initial resources")
 
-	server, err := server.CreateHTTPServer(validate, cores, configurations.DevMode)
+	cookie, err := sessionCookie(configurations)
+	noError(err, "Error creating session cookie")
+
+	server, err := server.CreateHTTPServer(
+		validate,
+		cores,
+		configurations.DevMode,
+		cookie,
+		configurations.Session.ReauthenticationMaxAge,
+		configurations.Session.ImpersonatorRole,
+		configurations.Role.Name,
+	)
 	noError(err, "Error creating server")
 
 	err = server.Listen(":8080")
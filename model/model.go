@@ -1,7 +1,9 @@
 package model
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"time"
@@ -47,6 +49,41 @@ func ParseID(id string) (ID, error) {
 	return ID(idUUID), nil
 }
 
+// Pagination selects a page of a list, at most Qt items after the opaque
+// Cursor, where an empty cursor is the first page. Total also counts all the
+// items of the list.
+type Pagination struct {
+	Cursor string
+	Qt     int
+	Total  bool
+}
+
+func FirstPage(qt int) Pagination {
+	return Pagination{Cursor: "", Qt: qt, Total: false}
+}
+
+// Page is a page of a list, NextCursor is empty on the last page and Total is
+// only set when it was requested.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+func EmptyPage[T any]() Page[T] {
+	return Page[T]{Items: []T{}, NextCursor: "", Total: nil}
+}
+
+// Deleted selects the soft deleted records of a list, by default they are
+// excluded.
+type Deleted int
+
+const (
+	ExcludeDeleted Deleted = iota
+	IncludeDeleted
+	OnlyDeleted
+)
+
 type RolePartial struct {
 	Name string `config:"name" json:"name" validate:"required,max=255"`
 }
@@ -72,99 +109,323 @@ func CustomValidationUsername(field validator.FieldLevel) bool {
 }
 
 type UserPartial struct {
-	Name     string   `config:"name"     json:"name"     validate:"required,max=255"`
-	Username string   `config:"username" json:"username" validate:"required,username,max=255"`
-	Email    string   `config:"email"    json:"email"    validate:"required,email,max=255"`
-	Password string   `config:"password" json:"password" validate:"required,max=255"`
-	Roles    []string `                  json:"roles"    validate:"omitempty"`
+	Name               string   `config:"name"     json:"name"               validate:"required,max=255"`
+	Username           string   `config:"username" json:"username"           validate:"required,username,max=255"`
+	Email              string   `config:"email"    json:"email"              validate:"required,email,max=255"`
+	Password           string   `config:"password" json:"password"           validate:"required,max=255"`
+	Roles              []string `                  json:"roles"              validate:"omitempty"`
+	MustChangePassword bool     `                  json:"mustChangePassword" validate:""`
+}
+
+type KeycloakCredential struct {
+	SecretData     string `json:"secretData"     validate:"required"`
+	CredentialData string `json:"credentialData" validate:"required"`
+}
+
+type UserImport struct {
+	Name               string              `json:"name"               validate:"required,max=255"`
+	Username           string              `json:"username"           validate:"required,username,max=255"`
+	Email              string              `json:"email"              validate:"required,email,max=255"`
+	PasswordHash       string              `json:"passwordHash"       validate:"required_without=Keycloak,excluded_with=Keycloak,max=255"`
+	Keycloak           *KeycloakCredential `json:"keycloak"           validate:"required_without=PasswordHash,excluded_with=PasswordHash"`
+	Roles              []string            `json:"roles"              validate:"omitempty"`
+	MustChangePassword bool                `json:"mustChangePassword" validate:""`
 }
 
 type UserUpdate struct {
-	Name     string   `json:"name"     validate:"omitempty,max=255"`
-	Username string   `json:"username" validate:"omitempty,username,max=255"`
-	Email    string   `json:"email"    validate:"omitempty,email,max=255"`
-	Password string   `json:"password" validate:"omitempty,max=255"`
-	Roles    []string `json:"roles"    validate:"omitempty"`
-	IsActive *bool    `json:"isActive" validate:"omitempty"`
+	Name               string   `json:"name"               validate:"omitempty,max=255"`
+	Username           string   `json:"username"           validate:"omitempty,username,max=255"`
+	Email              string   `json:"email"              validate:"omitempty,email,max=255"`
+	Password           string   `json:"password"           validate:"omitempty,max=255"`
+	Roles              []string `json:"roles"              validate:"omitempty"`
+	IsActive           *bool    `json:"isActive"           validate:"omitempty"`
+	MustChangePassword *bool    `json:"mustChangePassword" validate:"omitempty"`
+}
+
+type UserSelfUpdate struct {
+	Name     string `json:"name"     validate:"omitempty,max=255"`
+	Username string `json:"username" validate:"omitempty,username,max=255"`
+	Email    string `json:"email"    validate:"omitempty,email,max=255"`
+}
+
+type PasswordUpdate struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword"     validate:"required,max=255"`
 }
 
 type User struct {
-	ID        ID        `json:"id"`
-	Name      string    `json:"name"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password,omitempty"`
-	Roles     []string  `json:"roles,omitempty"`
-	IsActive  bool      `json:"isActive"`
-	CreatedAt time.Time `json:"createdAt"`
-	CreatedBy ID        `json:"createdBy"`
-	DeletedAt time.Time `json:"deletedAt,omitempty"`
-	DeletedBy ID        `json:"deletedBy,omitempty"`
+	ID                 ID        `json:"id"`
+	Name               string    `json:"name"`
+	Username           string    `json:"username"`
+	Email              string    `json:"email"`
+	Password           string    `json:"-"`
+	Roles              []string  `json:"roles,omitempty"`
+	IsActive           bool      `json:"isActive"`
+	PasswordChangedAt  time.Time `json:"passwordChangedAt"`
+	MustChangePassword bool      `json:"mustChangePassword"`
+	CreatedAt          time.Time `json:"createdAt"`
+	CreatedBy          ID        `json:"createdBy"`
+	DeletedAt          time.Time `json:"deletedAt,omitempty"`
+	DeletedBy          ID        `json:"deletedBy,omitempty"`
+	Version            int       `json:"version"`
 }
 
+// AnyVersion skips the version check when updating a user.
+const AnyVersion = 0
+
 func (u *User) Postgres() UserPostgres {
 	return UserPostgres{
-		ID:        u.ID,
-		Name:      u.Name,
-		Username:  u.Username,
-		Email:     u.Email,
-		Password:  u.Password,
-		IsActive:  u.IsActive,
-		Roles:     u.Roles,
-		CreatedAt: u.CreatedAt,
-		CreatedBy: u.CreatedBy,
-		DeletedAt: u.DeletedAt,
-		DeletedBy: u.DeletedBy,
+		ID:                 u.ID,
+		Name:               u.Name,
+		Username:           u.Username,
+		Email:              u.Email,
+		Password:           u.Password,
+		IsActive:           u.IsActive,
+		Roles:              u.Roles,
+		PasswordChangedAt:  u.PasswordChangedAt,
+		MustChangePassword: u.MustChangePassword,
+		CreatedAt:          u.CreatedAt,
+		CreatedBy:          u.CreatedBy,
+		DeletedAt:          u.DeletedAt,
+		DeletedBy:          u.DeletedBy,
+		Version:            u.Version,
 	}
 }
 
+func (u *User) Response() UserResponse {
+	return UserResponse{
+		ID:                 u.ID,
+		Name:               u.Name,
+		Username:           u.Username,
+		Email:              u.Email,
+		Roles:              u.Roles,
+		IsActive:           u.IsActive,
+		PasswordChangedAt:  u.PasswordChangedAt,
+		MustChangePassword: u.MustChangePassword,
+		CreatedAt:          u.CreatedAt,
+		CreatedBy:          u.CreatedBy,
+		DeletedAt:          u.DeletedAt,
+		DeletedBy:          u.DeletedBy,
+		Version:            u.Version,
+	}
+}
+
+// UserResponse is the user sent by the API, it has no password hash.
+type UserResponse struct {
+	ID                 ID        `json:"id"`
+	Name               string    `json:"name"`
+	Username           string    `json:"username"`
+	Email              string    `json:"email"`
+	Roles              []string  `json:"roles,omitempty"`
+	IsActive           bool      `json:"isActive"`
+	PasswordChangedAt  time.Time `json:"passwordChangedAt"`
+	MustChangePassword bool      `json:"mustChangePassword"`
+	CreatedAt          time.Time `json:"createdAt"`
+	CreatedBy          ID        `json:"createdBy"`
+	DeletedAt          time.Time `json:"deletedAt,omitempty"`
+	DeletedBy          ID        `json:"deletedBy,omitempty"`
+	Version            int       `json:"version"`
+}
+
+func UsersResponse(users []User) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, user.Response())
+	}
+
+	return responses
+}
+
 var (
 	EmptyUser  = User{}   //nolint:exhaustruct,gochecknoglobals
 	EmptyUsers = []User{} //nolint:gochecknoglobals
 )
 
+// UserSummary identifies a user when it is embedded in another resource.
+type UserSummary struct {
+	ID       ID     `json:"id"       db:"id"`
+	Name     string `json:"name"     db:"name"`
+	Username string `json:"username" db:"username"`
+}
+
+const (
+	ExpandRoles     = "roles"
+	ExpandCreatedBy = "createdBy"
+)
+
+// UserExpansions has the resources referenced by users, a nil map means the
+// expansion was not requested.
+type UserExpansions struct {
+	Roles map[string]Role
+	Users map[ID]UserSummary
+}
+
+var EmptyUserExpansions = UserExpansions{} //nolint:exhaustruct,gochecknoglobals
+
+const (
+	UserSortName              = "name"
+	UserSortUsername          = "username"
+	UserSortEmail             = "email"
+	UserSortCreatedAt         = "createdAt"
+	UserSortPasswordChangedAt = "passwordChangedAt"
+)
+
+var UserSortFields = []string{ //nolint:gochecknoglobals
+	UserSortName,
+	UserSortUsername,
+	UserSortEmail,
+	UserSortCreatedAt,
+	UserSortPasswordChangedAt,
+}
+
+type UserSort struct {
+	Field      string
+	Descending bool
+}
+
+// UserFilter searches users, empty fields do not filter. Name, Username and
+// Email match substrings ignoring case and the creation range is inclusive.
+type UserFilter struct {
+	Name        string
+	Username    string
+	Email       string
+	IsActive    *bool
+	Role        string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	CreatedBy   ID
+	Deleted     Deleted
+	Sort        []UserSort
+}
+
 type UserPostgres struct {
-	ID        ID             `db:"id"`
-	Name      string         `db:"name"`
-	Username  string         `db:"username"`
-	Email     string         `db:"email"`
-	Password  string         `db:"password"`
-	Roles     pq.StringArray `db:"roles"`
-	IsActive  bool           `db:"is_active"`
-	CreatedAt time.Time      `db:"created_at"`
-	CreatedBy ID             `db:"created_by"`
-	DeletedAt time.Time      `db:"deleted_at"`
-	DeletedBy ID             `db:"deleted_by"`
+	ID                 ID             `db:"id"`
+	Name               string         `db:"name"`
+	Username           string         `db:"username"`
+	Email              string         `db:"email"`
+	Password           string         `db:"password"`
+	Roles              pq.StringArray `db:"roles"`
+	IsActive           bool           `db:"is_active"`
+	PasswordChangedAt  time.Time      `db:"password_changed_at"`
+	MustChangePassword bool           `db:"must_change_password"`
+	CreatedAt          time.Time      `db:"created_at"`
+	CreatedBy          ID             `db:"created_by"`
+	DeletedAt          time.Time      `db:"deleted_at"`
+	DeletedBy          ID             `db:"deleted_by"`
+	Version            int            `db:"version"`
 }
 
 func (u *UserPostgres) User() User {
 	return User{
-		ID:        u.ID,
-		Name:      u.Name,
-		Username:  u.Username,
-		Email:     u.Email,
-		Password:  u.Password,
-		IsActive:  u.IsActive,
-		Roles:     u.Roles,
-		CreatedAt: u.CreatedAt,
-		CreatedBy: u.CreatedBy,
-		DeletedAt: u.DeletedAt,
-		DeletedBy: u.DeletedBy,
+		ID:                 u.ID,
+		Name:               u.Name,
+		Username:           u.Username,
+		Email:              u.Email,
+		Password:           u.Password,
+		IsActive:           u.IsActive,
+		Roles:              u.Roles,
+		PasswordChangedAt:  u.PasswordChangedAt,
+		MustChangePassword: u.MustChangePassword,
+		CreatedAt:          u.CreatedAt,
+		CreatedBy:          u.CreatedBy,
+		DeletedAt:          u.DeletedAt,
+		DeletedBy:          u.DeletedBy,
+		Version:            u.Version,
 	}
 }
 
 type UserSessionPartial struct {
-	Username string `json:"username" validate:"required_without=Email,excluded_with=Email"`
-	Email    string `json:"email"    validate:"required_without=Username,excluded_with=Username,omitempty,email"`
-	Password string `json:"password" validate:"required"`
+	Username   string `json:"username"   validate:"required_without=Email,excluded_with=Email"`
+	Email      string `json:"email"      validate:"required_without=Username,excluded_with=Username,omitempty,email"`
+	Password   string `json:"password"   validate:"required"`
+	DeviceName string `json:"deviceName" validate:"max=255"`
+	RememberMe bool   `json:"rememberMe" validate:""`
+	IP         string `json:"-"          validate:"-"`
+	UserAgent  string `json:"-"          validate:"-"`
 }
 
 type UserSession struct {
-	ID        ID        `json:"id"                  db:"id"`
-	UserID    ID        `json:"userId"              db:"userid"`
-	CreateaAt time.Time `json:"createdAt"           db:"created_at"`
-	Expires   time.Time `json:"expires"             db:"expires"`
-	DeletedAt time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	ID                   ID        `json:"id"                   db:"id"`
+	UserID               ID        `json:"userId"               db:"userid"`
+	CreateaAt            time.Time `json:"createdAt"            db:"created_at"`
+	OriginalCreatedAt    time.Time `json:"originalCreatedAt"    db:"original_created_at"`
+	Expires              time.Time `json:"expires"              db:"expires"`
+	DeletedAt            time.Time `json:"deletedAt,omitempty"  db:"deleted_at"`
+	Restricted           bool      `json:"restricted"           db:"restricted"`
+	RememberMe           bool      `json:"rememberMe"           db:"remember_me"`
+	AuthenticatedAt      time.Time `json:"authenticatedAt"      db:"authenticated_at"`
+	AuthenticationMethod string    `json:"authenticationMethod" db:"authentication_method"`
+	ImpersonatorID       ID        `json:"impersonatorId"       db:"impersonator_id"`
+	IP                   string    `json:"ip"                   db:"ip"`
+	UserAgent            string    `json:"userAgent"            db:"user_agent"`
+	Device               string    `json:"device"               db:"device"`
+	DeviceName           string    `json:"deviceName"           db:"device_name"`
+}
+
+// Response returns the session without its ID, which is the credential of the
+// session. Handle identifies the session in listings and can not be used to
+// authenticate.
+func (u *UserSession) Response() UserSessionResponse {
+	hash := sha256.Sum256([]byte(u.ID.String()))
+
+	return UserSessionResponse{
+		Handle:               hex.EncodeToString(hash[:]),
+		UserID:               u.UserID,
+		CreateaAt:            u.CreateaAt,
+		OriginalCreatedAt:    u.OriginalCreatedAt,
+		Expires:              u.Expires,
+		DeletedAt:            u.DeletedAt,
+		Restricted:           u.Restricted,
+		RememberMe:           u.RememberMe,
+		AuthenticatedAt:      u.AuthenticatedAt,
+		AuthenticationMethod: u.AuthenticationMethod,
+		ImpersonatorID:       u.ImpersonatorID,
+		IP:                   u.IP,
+		UserAgent:            u.UserAgent,
+		Device:               u.Device,
+		DeviceName:           u.DeviceName,
+	}
+}
+
+type UserSessionResponse struct {
+	Handle               string    `json:"handle"`
+	UserID               ID        `json:"userId"`
+	CreateaAt            time.Time `json:"createdAt"`
+	OriginalCreatedAt    time.Time `json:"originalCreatedAt"`
+	Expires              time.Time `json:"expires"`
+	DeletedAt            time.Time `json:"deletedAt,omitempty"`
+	Restricted           bool      `json:"restricted"`
+	RememberMe           bool      `json:"rememberMe"`
+	AuthenticatedAt      time.Time `json:"authenticatedAt"`
+	AuthenticationMethod string    `json:"authenticationMethod"`
+	ImpersonatorID       ID        `json:"impersonatorId"`
+	IP                   string    `json:"ip"`
+	UserAgent            string    `json:"userAgent"`
+	Device               string    `json:"device"`
+	DeviceName           string    `json:"deviceName"`
+}
+
+func UserSessionsResponse(page Page[UserSession]) Page[UserSessionResponse] {
+	responses := make([]UserSessionResponse, 0, len(page.Items))
+	for _, userSession := range page.Items {
+		responses = append(responses, userSession.Response())
+	}
+
+	return Page[UserSessionResponse]{
+		Items:      responses,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+}
+
+const (
+	AuthenticationPassword         = "password"
+	AuthenticationReauthentication = "reauthentication"
+	AuthenticationImpersonation    = "impersonation"
+)
+
+type Reauthentication struct {
+	Password string `json:"password" validate:"required"`
 }
 
 var (
@@ -172,6 +433,92 @@ var (
 	EmptyUserSessions = []UserSession{} //nolint:gochecknoglobals
 )
 
+type TokenPartial struct {
+	Name    string    `json:"name"    validate:"required,max=255"`
+	Expires time.Time `json:"expires" validate:""`
+	Roles   []string  `json:"roles"   validate:"omitempty"`
+}
+
+type Token struct {
+	ID        ID        `json:"id"`
+	UserID    ID        `json:"userId"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"-"`
+	Roles     []string  `json:"roles"`
+	Expires   time.Time `json:"expires,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy ID        `json:"createdBy"`
+	DeletedAt time.Time `json:"deletedAt,omitempty"`
+	DeletedBy ID        `json:"deletedBy,omitempty"`
+}
+
+// Expired reports if the token has an expiration and it is before now.
+func (t *Token) Expired(now time.Time) bool {
+	return !t.Expires.IsZero() && !now.Before(t.Expires)
+}
+
+func (t *Token) Postgres() TokenPostgres {
+	return TokenPostgres{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Hash:      t.Hash,
+		Roles:     t.Roles,
+		Expires:   t.Expires,
+		CreatedAt: t.CreatedAt,
+		CreatedBy: t.CreatedBy,
+		DeletedAt: t.DeletedAt,
+		DeletedBy: t.DeletedBy,
+	}
+}
+
+// TokenCreated is only returned when the token is created, it is the only
+// time the token secret is available.
+type TokenCreated struct {
+	Token
+	Secret string `json:"token"`
+}
+
+// TokenAuthenticated is a token used in a request, restricted like a session
+// when the password of its user has to be changed.
+type TokenAuthenticated struct {
+	Token
+	Restricted bool
+}
+
+var (
+	EmptyToken  = Token{}   //nolint:exhaustruct,gochecknoglobals
+	EmptyTokens = []Token{} //nolint:gochecknoglobals
+)
+
+type TokenPostgres struct {
+	ID        ID             `db:"id"`
+	UserID    ID             `db:"userid"`
+	Name      string         `db:"name"`
+	Hash      string         `db:"hash"`
+	Roles     pq.StringArray `db:"roles"`
+	Expires   time.Time      `db:"expires"`
+	CreatedAt time.Time      `db:"created_at"`
+	CreatedBy ID             `db:"created_by"`
+	DeletedAt time.Time      `db:"deleted_at"`
+	DeletedBy ID             `db:"deleted_by"`
+}
+
+func (t *TokenPostgres) Token() Token {
+	return Token{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Hash:      t.Hash,
+		Roles:     t.Roles,
+		Expires:   t.Expires,
+		CreatedAt: t.CreatedAt,
+		CreatedBy: t.CreatedBy,
+		DeletedAt: t.DeletedAt,
+		DeletedBy: t.DeletedBy,
+	}
+}
+
 func Validate() *validator.Validate {
 	validate := validator.New()
 
@@ -1,6 +1,7 @@
 package model_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -101,6 +102,65 @@ func TestUser(t *testing.T) {
 	require.Equal(t, postgres.User(), user)
 }
 
+func TestUserResponse(t *testing.T) {
+	t.Parallel()
+
+	user := model.User{
+		ID:        model.NewID(),
+		Name:      gofakeit.Name(),
+		Username:  gofakeit.Username(),
+		Email:     gofakeit.Email(),
+		Password:  "$argon2id$v=19$m=65536,t=1,p=2$" + gofakeit.LetterN(64),
+		Roles:     []string{gofakeit.Name(), gofakeit.Name(), gofakeit.Name()},
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		CreatedBy: model.NewID(),
+		DeletedAt: gofakeit.FutureDate(),
+		DeletedBy: model.NewID(),
+	}
+
+	response := user.Response()
+	require.Equal(t, user.ID, response.ID)
+	require.Equal(t, user.Username, response.Username)
+	require.Equal(t, user.Roles, response.Roles)
+
+	for _, value := range []any{user, response, model.UsersResponse([]model.User{user})} {
+		serial, err := json.Marshal(value)
+		require.NoError(t, err)
+		require.NotContains(t, string(serial), user.Password)
+		require.NotContains(t, string(serial), `"password"`)
+	}
+}
+
+func TestUserSessionResponse(t *testing.T) {
+	t.Parallel()
+
+	userSession := model.UserSession{ //nolint:exhaustruct
+		ID:        model.NewID(),
+		UserID:    model.NewID(),
+		CreateaAt: time.Now(),
+		Expires:   gofakeit.FutureDate(),
+		IP:        gofakeit.IPv4Address(),
+	}
+
+	response := userSession.Response()
+	require.Equal(t, userSession.UserID, response.UserID)
+	require.Equal(t, userSession.IP, response.IP)
+	require.NotEmpty(t, response.Handle)
+	require.Equal(t, response.Handle, userSession.Response().Handle)
+
+	page := model.UserSessionsResponse(model.Page[model.UserSession]{ //nolint:exhaustruct
+		Items:      []model.UserSession{userSession},
+		NextCursor: "next",
+	})
+	require.Equal(t, "next", page.NextCursor)
+
+	serial, err := json.Marshal(page)
+	require.NoError(t, err)
+	require.NotContains(t, string(serial), userSession.ID.String())
+	require.NotContains(t, string(serial), `"id"`)
+}
+
 func TestValidate(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+// queryList reads a comma separated query param.
+func queryList(handler *fiber.Ctx, key string) []string {
+	values := []string{}
+
+	for _, value := range strings.Split(handler.Query(key), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// jsonFields returns the JSON names of the fields of a struct.
+func jsonFields(resource any) []string {
+	resourceType := reflect.TypeOf(resource)
+	fields := make([]string, 0, resourceType.NumField())
+
+	for i := 0; i < resourceType.NumField(); i++ {
+		name, _, _ := strings.Cut(resourceType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// fieldset selects the attributes sent of a resource, an empty fieldset sends
+// all of them.
+type fieldset []string
+
+// parseFieldset reads the fields query param, checking the fields exist in
+// the resource.
+func parseFieldset(handler *fiber.Ctx, resource any) (fieldset, error) {
+	fields := queryList(handler, "fields")
+	valid := jsonFields(resource)
+
+	for _, field := range fields {
+		if !slices.Contains(valid, field) {
+			return nil, fmt.Errorf("%w: %s", errs.ErrInvalidField, field)
+		}
+	}
+
+	return fields, nil
+}
+
+func toMap(resource any) (map[string]any, error) {
+	serial, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing resource: %w", err)
+	}
+
+	resourceMap := map[string]any{}
+
+	err = json.Unmarshal(serial, &resourceMap)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing resource: %w", err)
+	}
+
+	return resourceMap, nil
+}
+
+func (f fieldset) apply(resource map[string]any) map[string]any {
+	if len(f) == 0 {
+		return resource
+	}
+
+	selected := make(map[string]any, len(f))
+
+	for _, field := range f {
+		if value, ok := resource[field]; ok {
+			selected[field] = value
+		}
+	}
+
+	return selected
+}
+
+// resource returns the resource with only the fields of the fieldset.
+func (f fieldset) resource(resource any) (any, error) {
+	if len(f) == 0 {
+		return resource, nil
+	}
+
+	resourceMap, err := toMap(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.apply(resourceMap), nil
+}
+
+// userResource builds the user sent by the API, replacing the references by
+// the expanded resources and keeping only the fields of the fieldset.
+func userResource(
+	user model.User,
+	expansions model.UserExpansions,
+	fields fieldset,
+) (any, error) {
+	response := user.Response()
+
+	if expansions.Roles == nil && expansions.Users == nil {
+		return fields.resource(response)
+	}
+
+	resource, err := toMap(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if expansions.Roles != nil {
+		roles := make([]model.Role, 0, len(user.Roles))
+
+		for _, name := range user.Roles {
+			if role, ok := expansions.Roles[name]; ok {
+				roles = append(roles, role)
+			}
+		}
+
+		resource[model.ExpandRoles] = roles
+	}
+
+	if creator, ok := expansions.Users[user.CreatedBy]; ok {
+		resource[model.ExpandCreatedBy] = creator
+	}
+
+	return fields.apply(resource), nil
+}
+
+func usersResource(
+	users []model.User,
+	expansions model.UserExpansions,
+	fields fieldset,
+) ([]any, error) {
+	resources := make([]any, 0, len(users))
+
+	for _, user := range users {
+		resource, err := userResource(user, expansions, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
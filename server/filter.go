@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+// queryTime reads a RFC 3339 query param, a missing param is the zero time.
+func queryTime(handler *fiber.Ctx, key string) (time.Time, error) {
+	value := handler.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be a RFC 3339 time", errs.ErrInvalidFilter, key)
+	}
+
+	return parsed, nil
+}
+
+// parseSort reads the sort query param, a list of fields where a leading '-'
+// sorts descending, like "name,-createdAt".
+func parseSort(handler *fiber.Ctx) []model.UserSort {
+	fields := queryList(handler, "sort")
+	sorts := make([]model.UserSort, 0, len(fields))
+
+	for _, field := range fields {
+		descending := strings.HasPrefix(field, "-")
+		sorts = append(sorts, model.UserSort{
+			Field:      strings.TrimPrefix(field, "-"),
+			Descending: descending,
+		})
+	}
+
+	return sorts
+}
+
+// parseDeleted reads the includeDeleted and onlyDeleted query params, by
+// default deleted records are excluded.
+func parseDeleted(handler *fiber.Ctx) (model.Deleted, error) {
+	include, only := handler.QueryBool("includeDeleted"), handler.QueryBool("onlyDeleted")
+
+	switch {
+	case include && only:
+		return model.ExcludeDeleted,
+			fmt.Errorf("%w: includeDeleted and onlyDeleted are exclusive", errs.ErrInvalidFilter)
+	case include:
+		return model.IncludeDeleted, nil
+	case only:
+		return model.OnlyDeleted, nil
+	default:
+		return model.ExcludeDeleted, nil
+	}
+}
+
+// parseUserFilter reads the user search query params.
+func parseUserFilter(handler *fiber.Ctx) (model.UserFilter, error) {
+	filter := model.UserFilter{ //nolint:exhaustruct
+		Name:     handler.Query("name"),
+		Username: handler.Query("username"),
+		Email:    handler.Query("email"),
+		Role:     handler.Query("role"),
+		Sort:     parseSort(handler),
+	}
+
+	if isActive := handler.Query("isActive"); isActive != "" {
+		value, err := strconv.ParseBool(isActive)
+		if err != nil {
+			return model.UserFilter{}, //nolint:exhaustruct
+				fmt.Errorf("%w: isActive must be a boolean", errs.ErrInvalidFilter)
+		}
+
+		filter.IsActive = &value
+	}
+
+	var err error
+
+	filter.Deleted, err = parseDeleted(handler)
+	if err != nil {
+		return model.UserFilter{}, err //nolint:exhaustruct
+	}
+
+	filter.CreatedFrom, err = queryTime(handler, "createdFrom")
+	if err != nil {
+		return model.UserFilter{}, err //nolint:exhaustruct
+	}
+
+	filter.CreatedTo, err = queryTime(handler, "createdTo")
+	if err != nil {
+		return model.UserFilter{}, err //nolint:exhaustruct
+	}
+
+	if createdBy := handler.Query("createdBy"); createdBy != "" {
+		filter.CreatedBy, err = model.ParseID(createdBy)
+		if err != nil {
+			return model.UserFilter{}, //nolint:exhaustruct
+				fmt.Errorf("%w: createdBy must be a user ID", errs.ErrInvalidFilter)
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+// Get the current user
+//
+//	@Summary		Get current user
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	model.UserResponse	"current user"
+//	@Failure		401	{object}	sent				"user session has expired"
+//	@Failure		500	{object}	sent				"internal server error"
+//	@Router			/me [get]
+//	@Description	Get the user of the current session.
+//	@Security		BasicAuth
+func (u *User) GetMe(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	funcCore := func() (model.UserResponse, error) {
+		user, err := u.core.GetByID(userID)
+
+		return user.Response(), err
+	}
+
+	expectErrors := []expectError{{errs.ErrUserNotFound, fiber.StatusUnauthorized}}
+
+	unexpectMessageError := "error getting user"
+
+	return callingCoreWithReturn(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		fiber.StatusOK,
+		u.getTranslator(handler),
+		handler,
+	)
+}
+
+// Update the current user
+//
+//	@Summary		Update current user
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Success		200		{object}	sent					"update user successfully"
+//	@Failure		400		{object}	sent					"an invalid user param was sent"
+//	@Failure		401		{object}	sent					"user session has expired or must be reauthenticated"
+//	@Failure		409		{object}	sent					"username/email already exist or user was modified concurrently"
+//	@Failure		500		{object}	sent					"internal server error"
+//	@Param			user	body		model.UserSelfUpdate	true	"user params"
+//	@Router			/me [put]
+//	@Description	Update the name, username and email of the user of the current session.
+//	@Security		BasicAuth
+func (u *User) UpdateMe(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	body := &model.UserSelfUpdate{} //nolint:exhaustruct
+
+	err := handler.BodyParser(body)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	funcCore := func() error { return u.core.UpdateSelf(userID, *body) }
+
+	expectErrors := []expectError{
+		{errs.ErrUserNotFound, fiber.StatusUnauthorized},
+		{errs.ErrUsernameAlreadyExist, fiber.StatusConflict},
+		{errs.ErrEmailAlreadyExist, fiber.StatusConflict},
+		{errs.ErrUserVersionMismatch, fiber.StatusConflict},
+	}
+
+	unexpectMessageError := "error updating user"
+
+	okay := okay{"user updated", fiber.StatusOK}
+
+	return callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+}
+
+// Change the password of the current user
+//
+//	@Summary		Change current user password
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	sent					"password changed successfully"
+//	@Failure		400			{object}	sent					"an invalid password param was sent"
+//	@Failure		401			{object}	sent					"user session has expired"
+//	@Failure		409			{object}	sent					"user was modified concurrently"
+//	@Failure		500			{object}	sent					"internal server error"
+//	@Failure		503			{object}	sent					"too many password operations"
+//	@Param			password	body		model.PasswordUpdate	true	"password params"
+//	@Router			/me/password [put]
+//	@Description	Change the password of the user of the current session after checking the current password.
+//	@Security		BasicAuth
+func (u *User) ChangeMyPassword(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	body := &model.PasswordUpdate{} //nolint:exhaustruct
+
+	err := handler.BodyParser(body)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	funcCore := func() error { return u.core.ChangePassword(userID, *body) }
+
+	expectErrors := []expectError{
+		{errs.ErrUserNotFound, fiber.StatusUnauthorized},
+		{errs.ErrPasswordDoesNotMatch, fiber.StatusBadRequest},
+		{errs.ErrPasswordPoolFull, fiber.StatusServiceUnavailable},
+		{errs.ErrUserVersionMismatch, fiber.StatusConflict},
+	}
+
+	unexpectMessageError := "error changing password"
+
+	okay := okay{"password changed", fiber.StatusOK}
+
+	return callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+}
+
+// Delete the current user
+//
+//	@Summary		Delete current user
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	sent	"user deleted"
+//	@Failure		401	{object}	sent	"user session has expired or must be reauthenticated"
+//	@Failure		500	{object}	sent	"internal server error"
+//	@Router			/me [delete]
+//	@Description	Delete the user of the current session, revoking all their sessions and tokens.
+//	@Security		BasicAuth
+func (u *User) DeleteMe(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	funcCore := func() error { return u.deleteUser(userID, userID) }
+
+	expectErrors := []expectError{{errs.ErrUserNotFound, fiber.StatusUnauthorized}}
+
+	unexpectMessageError := "error deleting user"
+
+	okay := okay{"user deleted", fiber.StatusOK}
+
+	return callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+const maxQtResults = 1000
+
+// parsePagination reads the cursor, qt and total query params, an invalid qt
+// is replaced by the default and a big one is limited.
+func parsePagination(handler *fiber.Ctx) model.Pagination {
+	qt := handler.QueryInt("qt", defaultQtResults)
+	if qt < 1 {
+		qt = defaultQtResults
+	}
+
+	return model.Pagination{
+		Cursor: handler.Query("cursor"),
+		Qt:     min(qt, maxQtResults),
+		Total:  handler.QueryBool("total"),
+	}
+}
+
+// linkNextPage sets the Link header to the next page, which is the current
+// URL with the next cursor. The last page has no link.
+func linkNextPage(handler *fiber.Ctx, cursor string) {
+	if cursor == "" {
+		return
+	}
+
+	link, err := url.Parse(handler.OriginalURL())
+	if err != nil {
+		return
+	}
+
+	query := link.Query()
+	query.Set("cursor", cursor)
+	link.RawQuery = query.Encode()
+
+	handler.Append(fiber.HeaderLink, fmt.Sprintf(`<%s%s>; rel="next"`, handler.BaseURL(), link))
+}
+
+// resourcesPage sends a page with its items converted to resources.
+func resourcesPage[T any](
+	handler *fiber.Ctx,
+	page model.Page[T],
+	resources []any,
+) model.Page[any] {
+	linkNextPage(handler, page.NextCursor)
+
+	return model.Page[any]{Items: resources, NextCursor: page.NextCursor, Total: page.Total}
+}
@@ -34,15 +34,29 @@ func (r *Role) getTranslator(handler *fiber.Ctx) ut.Translator { //nolint:iretur
 //	@Accept			json
 //	@Produce		json
 //	@Success		200		{object}	model.Role	"role return"
+//	@Failure		400		{object}	sent		"an invalid field param was sent"
 //	@Failure		401		{object}	sent		"user session has expired"
 //	@Failure		404		{object}	sent		"role does not exist"
 //	@Failure		500		{object}	sent		"internal server error"
 //	@Param			name	path		string		true	"role name"
+//	@Param			fields	query		string		false	"comma separated fields to return"
 //	@Router			/role/{name} [get]
 //	@Description	Get role by name.
 //	@Security		BasicAuth
 func (r *Role) GetByName(handler *fiber.Ctx) error {
-	funcCore := func() (model.Role, error) { return r.core.GetByName(handler.Params("name")) }
+	fields, err := parseFieldset(handler, model.Role{}) //nolint:exhaustruct
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	funcCore := func() (any, error) {
+		role, err := r.core.GetByName(handler.Params("name"))
+		if err != nil {
+			return nil, err
+		}
+
+		return fields.resource(role)
+	}
 
 	expectErrors := []expectError{{errs.ErrRoleNotFound, fiber.StatusNotFound}}
 
@@ -64,20 +78,55 @@ func (r *Role) GetByName(handler *fiber.Ctx) error {
 //	@Tags			role
 //	@Accept			json
 //	@Produce		json
-//	@Success		200		{array}		model.Role	"all roles"
-//	@Failure		401		{object}	sent		"user session has expired"
-//	@Failure		500		{object}	sent		"internal server error"
-//	@Param			page	query		string		false	"result page number"
-//	@Param			qt		query		string		false	"quantity roles per page"
+//	@Success		200				{object}	model.Page[model.Role]	"all roles"
+//	@Failure		400				{object}	sent					"an invalid field, deleted or cursor param was sent"
+//	@Failure		401				{object}	sent					"user session has expired"
+//	@Failure		403				{object}	sent					"deleted records were requested and the current user is not admin"
+//	@Failure		500				{object}	sent					"internal server error"
+//	@Param			cursor			query		string					false	"cursor of the page, from nextCursor"
+//	@Param			qt				query		string					false	"quantity roles per page"
+//	@Param			total			query		bool					false	"also count all the roles"
+//	@Param			fields			query		string					false	"comma separated fields to return"
+//	@Param			includeDeleted	query		bool					false	"also get the deleted roles"
+//	@Param			onlyDeleted		query		bool					false	"only get the deleted roles"
+//	@Header			200				{string}	Link					"link to the next page"
 //	@Router			/role [get]
-//	@Description	Get all roles
+//	@Description	Get all roles, sorted by name.
 //	@Security		BasicAuth
 func (r *Role) GetAll(handler *fiber.Ctx) error {
-	page, qt := handler.QueryInt("page"), handler.QueryInt("qt", defaultQtResults)
+	pagination := parsePagination(handler)
+
+	fields, err := parseFieldset(handler, model.Role{}) //nolint:exhaustruct
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	deleted, err := parseDeleted(handler)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	funcCore := func() (any, error) {
+		roles, err := r.core.GetAll(deleted, pagination)
+		if err != nil {
+			return nil, err
+		}
 
-	funcCore := func() ([]model.Role, error) { return r.core.GetAll(page, qt) }
+		resources := make([]any, 0, len(roles.Items))
 
-	expectErrors := []expectError{}
+		for _, role := range roles.Items {
+			resource, err := fields.resource(role)
+			if err != nil {
+				return nil, err
+			}
+
+			resources = append(resources, resource)
+		}
+
+		return resourcesPage(handler, roles, resources), nil
+	}
+
+	expectErrors := []expectError{{errs.ErrInvalidCursor, fiber.StatusBadRequest}}
 
 	unexpectMessageError := "error getting roles"
 
@@ -99,7 +148,7 @@ func (r *Role) GetAll(handler *fiber.Ctx) error {
 //	@Produce		json
 //	@Success		201		{object}	sent				"create role successfully"
 //	@Failure		400		{object}	sent				"an invalid role param was sent"
-//	@Failure		401		{object}	sent				"user session has expired"
+//	@Failure		401		{object}	sent				"user session has expired or must be reauthenticated"
 //	@Failure		403		{object}	sent				"current user is not admin"
 //	@Failure		409		{object}	sent				"role already exist"
 //	@Failure		500		{object}	sent				"internal server error"
@@ -148,7 +197,7 @@ func (r *Role) Create(handler *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Success		200		{object}	sent	"role deleted"
-//	@Failure		401		{object}	sent	"user session has expired"
+//	@Failure		401		{object}	sent	"user session has expired or must be reauthenticated"
 //	@Failure		403		{object}	sent	"current user is not admin"
 //	@Failure		404		{object}	sent	"role does not exist"
 //	@Failure		500		{object}	sent	"internal server error"
@@ -182,3 +231,49 @@ func (r *Role) Delete(handler *fiber.Ctx) error {
 		handler,
 	)
 }
+
+// Restore a role
+//
+//	@Summary		Restore role
+//	@Tags			role
+//	@Accept			json
+//	@Produce		json
+//	@Success		200		{object}	sent	"role restored"
+//	@Failure		401		{object}	sent	"user session has expired or must be reauthenticated"
+//	@Failure		403		{object}	sent	"current user is not admin"
+//	@Failure		404		{object}	sent	"role does not exist"
+//	@Failure		409		{object}	sent	"role is not deleted"
+//	@Failure		500		{object}	sent	"internal server error"
+//	@Param			name	path		string	true	"role name"
+//	@Router			/role/{name}/restore [post]
+//	@Description	Restore a deleted role, it is not given back to the users that had it.
+//	@Security		BasicAuth
+func (r *Role) Restore(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error getting user ID"})
+	}
+
+	funcCore := func() error { return r.core.Restore(userID, handler.Params("name")) }
+
+	expectErrors := []expectError{
+		{errs.ErrRoleNotFound, fiber.StatusNotFound},
+		{errs.ErrRoleNotDeleted, fiber.StatusConflict},
+	}
+
+	unexpectMessageError := "error restoring role"
+
+	okay := okay{"role restored", fiber.StatusOK}
+
+	return callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		r.getTranslator(handler),
+		handler,
+	)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/ansrivas/fiberprometheus/v2"
@@ -31,6 +32,11 @@ type sent struct {
 	Message string `json:"message"`
 }
 
+type translatableError interface {
+	error
+	Translate(language ut.Translator) string
+}
+
 type expectError struct {
 	err    error
 	status int
@@ -51,7 +57,7 @@ func callingCore(
 ) error {
 	err := coreFunc()
 	if err != nil {
-		modelInvalid := core.InvalidError{}
+		var modelInvalid translatableError
 		if okay := errors.As(err, &modelInvalid); okay {
 			return handler.Status(fiber.StatusBadRequest).
 				JSON(sent{modelInvalid.Translate(language)})
@@ -82,7 +88,7 @@ func callingCoreWithReturn[T any](
 ) error {
 	data, err := coreFunc()
 	if err != nil {
-		modelInvalid := core.InvalidError{}
+		var modelInvalid translatableError
 		if okay := errors.As(err, &modelInvalid); okay {
 			return handler.Status(fiber.StatusBadRequest).
 				JSON(sent{modelInvalid.Translate(language)})
@@ -113,6 +119,15 @@ func createTranslator(validate *validator.Validate) (*ut.UniversalTranslator, er
 		return nil, fmt.Errorf("error register 'en' translation: %w", err)
 	}
 
+	err = enTrans.Add(
+		core.PasswordReusedTranslation,
+		"password must be different from the last {0} passwords",
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error register 'en' translation: %w", err)
+	}
+
 	ptTrans, _ := translator.GetTranslator("pt")
 
 	err = ptTranslations.RegisterDefaultTranslations(validate, ptTrans)
@@ -120,6 +135,15 @@ func createTranslator(validate *validator.Validate) (*ut.UniversalTranslator, er
 		return nil, fmt.Errorf("error register 'pt' translation: %w", err)
 	}
 
+	err = ptTrans.Add(
+		core.PasswordReusedTranslation,
+		"a senha deve ser diferente das últimas {0} senhas",
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error register 'pt' translation: %w", err)
+	}
+
 	ptBRTrans, _ := translator.GetTranslator("pt_BR")
 
 	err = pt_br_translations.RegisterDefaultTranslations(validate, ptBRTrans)
@@ -127,6 +151,15 @@ func createTranslator(validate *validator.Validate) (*ut.UniversalTranslator, er
 		return nil, fmt.Errorf("error register 'pt_BR' translation: %w", err)
 	}
 
+	err = ptBRTrans.Add(
+		core.PasswordReusedTranslation,
+		"a senha deve ser diferente das últimas {0} senhas",
+		false,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error register 'pt_BR' translation: %w", err)
+	}
+
 	return translator, nil
 }
 
@@ -161,7 +194,15 @@ func registerDefaultMiddlewares(app *fiber.App) {
 		AllowMethods:     "GET, POST, PUT, DELETE",
 		AllowCredentials: true,
 		MaxAge:           10, //nolint:gomnd
-		ExposeHeaders:    "session",
+		ExposeHeaders: strings.Join([]string{
+			"session",
+			"session-expires",
+			"session-user",
+			"session-impersonator",
+			fiber.HeaderLink,
+			fiber.HeaderETag,
+			fiber.HeaderWWWAuthenticate,
+		}, ", "),
 		Next:             nil,
 		AllowOriginsFunc: nil,
 	}))
@@ -179,6 +220,10 @@ func CreateHTTPServer(
 	validate *validator.Validate,
 	cores *core.Cores,
 	devMode bool,
+	cookie Cookie,
+	reauthentication time.Duration,
+	impersonatorRole string,
+	adminRole string,
 ) (*fiber.App, error) {
 	app := fiber.New()
 
@@ -199,16 +244,29 @@ func CreateHTTPServer(
 
 	user := User{
 		core:       cores.User,
+		session:    cores.UserSession,
+		token:      cores.Token,
 		translator: translator,
 		languages:  languages,
 	}
 
-	session := UserSession{
-		core:       cores.UserSession,
+	token := Token{
+		core:       cores.Token,
 		translator: translator,
 		languages:  languages,
 	}
 
+	session := UserSession{
+		core:             cores.UserSession,
+		user:             cores.User,
+		token:            cores.Token,
+		translator:       translator,
+		languages:        languages,
+		cookie:           cookie,
+		impersonatorRole: impersonatorRole,
+		adminRole:        adminRole,
+	}
+
 	app.Post("/session", session.Create)
 
 	if devMode {
@@ -222,17 +280,43 @@ func CreateHTTPServer(
 		func(c *fiber.Ctx) error { return c.JSON(sent{"user session refresehed"}) },
 	)
 
-	app.Get("/role", role.GetAll)
-	app.Post("/role", role.Create)
+	app.Put("/session/password", session.SessionRequired, session.ChangePassword)
+
+	app.Use(session.Unrestricted)
+
+	app.Post("/session/reauthenticate", session.SessionRequired, session.Reauthenticate)
+
+	recent := session.RecentAuthentication(reauthentication)
+
+	app.Post("/session/impersonate/:userId", session.SessionRequired, recent, session.Impersonate)
+	app.Delete("/session/impersonate", session.SessionRequired, session.StopImpersonation)
+
+	app.Get("/me", user.GetMe)
+	app.Get("/me/session", session.GetMine)
+	app.Put("/me", recent, user.UpdateMe)
+	app.Put("/me/password", user.ChangeMyPassword)
+	app.Delete("/me", recent, user.DeleteMe)
+
+	app.Get("/role", session.AdminForDeleted, role.GetAll)
+	app.Post("/role", recent, role.Create)
 	app.Get("/role/:name", role.GetByName)
-	app.Delete("/role/:name", role.Delete)
+	app.Delete("/role/:name", recent, role.Delete)
+	app.Post("/role/:name/restore", session.AdminRequired, recent, role.Restore)
 
-	app.Get("/user", user.GetAll)
-	app.Post("/user", user.Create)
-	app.Get("/user/role", user.GetByRole)
+	app.Get("/user", session.AdminForDeleted, user.GetAll)
+	app.Post("/user", recent, user.Create)
+	app.Post("/user/import", recent, user.Import)
+	app.Get("/user/role", session.AdminForDeleted, user.GetByRole)
 	app.Get("/user/:id", user.GetByID)
-	app.Put("/user/:id", user.Update)
-	app.Delete("/user/:id", user.Delete)
+	app.Put("/user/:id", recent, user.Update)
+	app.Delete("/user/:id", recent, user.Delete)
+	app.Post("/user/:id/restore", session.AdminRequired, recent, user.Restore)
+
+	app.Get("/user/:id/session", session.SelfOrAdmin, session.GetByUserID)
+
+	app.Get("/user/:id/token", session.SelfOrAdmin, token.GetByUserID)
+	app.Post("/user/:id/token", session.SessionRequired, recent, token.Create)
+	app.Delete("/user/:id/token/:tokenId", session.SelfOrAdmin, token.Delete)
 
 	return app, nil
 }
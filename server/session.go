@@ -1,8 +1,14 @@
 package server
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	ut "github.com/go-playground/universal-translator"
@@ -13,13 +19,44 @@ import (
 )
 
 const (
-	invalidSession = "invalid_session"
+	invalidSession           = "invalid_session"
+	csrfHeader               = "X-CSRF-Token"
+	reauthenticationRequired = "reauthentication_required"
+	bearerPrefix             = "Bearer "
 )
 
+// challenge is sent when the session must be reauthenticated, Code allows
+// clients to tell it apart from an expired session.
+type challenge struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+	MaxAge  int    `json:"maxAge"`
+}
+
+// Cookie configures sessions sent in an HttpOnly cookie, so browsers do not
+// need to keep the session where JavaScript can read it. Requests authenticated
+// by the cookie must send the CSRF token, readable from the CSRFName cookie, in
+// the X-CSRF-Token header on unsafe methods.
+type Cookie struct {
+	Enabled    bool
+	Name       string
+	CSRFName   string
+	Domain     string
+	Path       string
+	Secure     bool
+	SameSite   string
+	CSRFSecret []byte
+}
+
 type UserSession struct {
-	core       *core.UserSession
-	translator *ut.UniversalTranslator
-	languages  []string
+	core             *core.UserSession
+	user             *core.User
+	token            *core.Token
+	translator       *ut.UniversalTranslator
+	languages        []string
+	cookie           Cookie
+	impersonatorRole string
+	adminRole        string
 }
 
 func (u *UserSession) getTranslator(handler *fiber.Ctx) ut.Translator { //nolint:ireturn
@@ -33,9 +70,60 @@ func (u *UserSession) getTranslator(handler *fiber.Ctx) ut.Translator { //nolint
 	return language
 }
 
-func setUserSession(handler *fiber.Ctx, userSession model.UserSession) {
+func (u *UserSession) setUserSession(handler *fiber.Ctx, userSession model.UserSession) {
 	handler.Set("session", userSession.ID.String())
 	handler.Set("session-expires", userSession.Expires.Format(time.RFC3339))
+	handler.Set("session-user", userSession.UserID.String())
+
+	if userSession.ImpersonatorID != model.EmptyID {
+		handler.Set("session-impersonator", userSession.ImpersonatorID.String())
+	}
+
+	if !u.cookie.Enabled {
+		return
+	}
+
+	handler.Cookie(&fiber.Cookie{ //nolint:exhaustruct
+		Name:     u.cookie.Name,
+		Value:    userSession.ID.String(),
+		Path:     u.cookie.Path,
+		Domain:   u.cookie.Domain,
+		Expires:  userSession.Expires,
+		Secure:   u.cookie.Secure,
+		HTTPOnly: true,
+		SameSite: u.cookie.SameSite,
+	})
+
+	handler.Cookie(&fiber.Cookie{ //nolint:exhaustruct
+		Name:     u.cookie.CSRFName,
+		Value:    u.csrfToken(userSession.ID),
+		Path:     u.cookie.Path,
+		Domain:   u.cookie.Domain,
+		Expires:  userSession.Expires,
+		Secure:   u.cookie.Secure,
+		HTTPOnly: false,
+		SameSite: u.cookie.SameSite,
+	})
+}
+
+// csrfToken is bound to the session by a HMAC, so a token can not be forged
+// or reused with another session without knowing the secret.
+func (u *UserSession) csrfToken(id model.ID) string {
+	mac := hmac.New(sha256.New, u.cookie.CSRFSecret)
+	mac.Write([]byte(id.String()))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (u *UserSession) validCSRFToken(handler *fiber.Ctx, id model.ID) bool {
+	switch handler.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+		return true
+	}
+
+	token := handler.Get(csrfHeader)
+
+	return token != "" && hmac.Equal([]byte(token), []byte(u.csrfToken(id)))
 }
 
 // Create a user session
@@ -46,8 +134,10 @@ func setUserSession(handler *fiber.Ctx, userSession model.UserSession) {
 //	@Produce		json
 //	@Success		201		{object}	sent						"session created successfully"
 //	@Failure		400		{object}	sent						"an invalid user param was sent"
+//	@Failure		403		{object}	sent						"maximum number of user sessions reached"
 //	@Failure		404		{object}	sent						"user does not exist"
 //	@Failure		500		{object}	sent						"internal server error"
+//	@Failure		503		{object}	sent						"too many password operations"
 //	@Param			user	body		model.UserSessionPartial	true	"user params"
 //	@Router			/session [post]
 //	@Description	Create a user session and set in the response header.
@@ -59,6 +149,9 @@ func (u *UserSession) Create(handler *fiber.Ctx) error {
 		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
 	}
 
+	body.IP = handler.IP()
+	body.UserAgent = handler.Get(fiber.HeaderUserAgent)
+
 	session := model.UserSession{} //nolint:exhaustruct
 
 	funcCore := func() error {
@@ -71,6 +164,8 @@ func (u *UserSession) Create(handler *fiber.Ctx) error {
 	expectErrors := []expectError{
 		{errs.ErrUserNotFound, fiber.StatusNotFound},
 		{errs.ErrPasswordDoesNotMatch, fiber.StatusBadRequest},
+		{errs.ErrUserSessionLimit, fiber.StatusForbidden},
+		{errs.ErrPasswordPoolFull, fiber.StatusServiceUnavailable},
 	}
 
 	unexpectMessageError := "error creating user session"
@@ -88,26 +183,68 @@ func (u *UserSession) Create(handler *fiber.Ctx) error {
 
 	deleteSession, ok := handler.Locals("deleteSession").(bool)
 	if !(ok && deleteSession) {
-		setUserSession(handler, session)
+		u.setUserSession(handler, session)
 	}
 
 	return err
 }
 
-func (u *UserSession) getSession(handler *fiber.Ctx) string {
+// getSession returns the session sent in the Session header or, when cookies
+// are enabled, in the session cookie, reporting if it came from the cookie.
+func (u *UserSession) getSession(handler *fiber.Ctx) (string, bool) {
 	header := handler.Get("Session", invalidSession)
-	if header == invalidSession {
-		return invalidSession
+	if header != invalidSession {
+		return header, false
 	}
 
-	return header
+	if u.cookie.Enabled {
+		cookie := handler.Cookies(u.cookie.Name, invalidSession)
+		if cookie != invalidSession {
+			return cookie, true
+		}
+	}
+
+	return invalidSession, false
+}
+
+// authenticateToken authenticates the request with a personal access token
+// instead of a session. A token is not an authentication of the user, so it
+// never counts as a recent one.
+func (u *UserSession) authenticateToken(handler *fiber.Ctx, secret string) error {
+	token, err := u.token.Authenticate(secret)
+	if err != nil {
+		if errors.Is(err, errs.ErrTokenNotFound) {
+			return handler.Status(fiber.StatusUnauthorized).
+				JSON(sent{errs.ErrTokenNotFound.Error()})
+		}
+
+		log.Printf("[ERROR] - error authenticating token: %s", err)
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error authenticating token"})
+	}
+
+	handler.Set("session-user", token.UserID.String())
+
+	handler.Locals("userID", token.UserID)
+	handler.Locals("tokenID", token.ID)
+	handler.Locals("roles", token.Roles)
+	handler.Locals("restricted", token.Restricted)
+	handler.Locals("impersonatorID", model.EmptyID)
+
+	return handler.Next()
 }
 
 func (u *UserSession) refresh(
 	handler *fiber.Ctx,
 	refresh func(model.ID) (model.UserSession, error),
 ) error {
-	sessionIDRaw := u.getSession(handler)
+	secret, found := strings.CutPrefix(handler.Get(fiber.HeaderAuthorization), bearerPrefix)
+	if found {
+		return u.authenticateToken(handler, secret)
+	}
+
+	sessionIDRaw, fromCookie := u.getSession(handler)
 	if sessionIDRaw == invalidSession {
 		return handler.Status(fiber.StatusUnauthorized).
 			JSON(sent{errs.ErrUserNotFound.Error()})
@@ -119,6 +256,11 @@ func (u *UserSession) refresh(
 			JSON(sent{errs.ErrUserNotFound.Error()})
 	}
 
+	if fromCookie && !u.validCSRFToken(handler, sessionID) {
+		return handler.Status(fiber.StatusForbidden).
+			JSON(sent{errs.ErrCSRFTokenInvalid.Error()})
+	}
+
 	session, err := refresh(sessionID)
 	if err != nil {
 		if errors.Is(err, errs.ErrUserSessionNotFound) {
@@ -132,9 +274,15 @@ func (u *UserSession) refresh(
 			JSON(sent{"error refreshing session"})
 	}
 
-	setUserSession(handler, session)
+	u.setUserSession(handler, session)
 
 	handler.Locals("userID", session.UserID)
+	handler.Locals("sessionID", session.ID)
+	handler.Locals("restricted", session.Restricted)
+	handler.Locals("rememberMe", session.RememberMe)
+	handler.Locals("authenticatedAt", session.AuthenticatedAt)
+	handler.Locals("authenticationMethod", session.AuthenticationMethod)
+	handler.Locals("impersonatorID", session.ImpersonatorID)
 
 	errNext := handler.Next()
 
@@ -149,9 +297,10 @@ func (u *UserSession) refresh(
 //	@Produce		json
 //	@Success		200	{object}	sent	"user session refreshed successfully"
 //	@Failure		401	{object}	sent	"user session has expired"
+//	@Failure		403	{object}	sent	"missing or invalid csrf token"
 //	@Failure		500	{object}	sent	"internal server error"
 //	@Router			/session [put]
-//	@Description	Refresh a user session and set in the response header.
+//	@Description	Refresh a user session and set in the response header. The session is only replaced when it is close to expiring.
 //	@Security		BasicAuth
 func (u *UserSession) Refresh(handler *fiber.Ctx) error {
 	return u.refresh(handler, u.core.Refresh)
@@ -160,3 +309,474 @@ func (u *UserSession) Refresh(handler *fiber.Ctx) error {
 func (u *UserSession) RefreshDev(handler *fiber.Ctx) error {
 	return u.refresh(handler, u.core.GetByID)
 }
+
+// Change the password of the session user
+//
+//	@Summary		Change password
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	sent					"password changed successfully"
+//	@Failure		400			{object}	sent					"an invalid password param was sent"
+//	@Failure		401			{object}	sent					"user session has expired"
+//	@Failure		403			{object}	sent					"operation requires a user session"
+//	@Failure		409			{object}	sent					"user was modified concurrently"
+//	@Failure		500			{object}	sent					"internal server error"
+//	@Failure		503			{object}	sent					"too many password operations"
+//	@Param			password	body		model.PasswordUpdate	true	"password params"
+//	@Router			/session/password [put]
+//	@Description	Change the password of the session user and set a new session in the response header.
+//	@Security		BasicAuth
+func (u *UserSession) ChangePassword(handler *fiber.Ctx) error {
+	sessionID, ok := handler.Locals("sessionID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting session ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	body := &model.PasswordUpdate{} //nolint:exhaustruct
+
+	err := handler.BodyParser(body)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	session := model.UserSession{} //nolint:exhaustruct
+
+	funcCore := func() error {
+		sessionTemp, err := u.core.ChangePassword(sessionID, *body)
+		session = sessionTemp
+
+		return err
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrUserSessionNotFound, fiber.StatusUnauthorized},
+		{errs.ErrUserNotFound, fiber.StatusUnauthorized},
+		{errs.ErrPasswordDoesNotMatch, fiber.StatusBadRequest},
+		{errs.ErrPasswordPoolFull, fiber.StatusServiceUnavailable},
+		{errs.ErrUserVersionMismatch, fiber.StatusConflict},
+	}
+
+	unexpectMessageError := "error changing password"
+
+	okay := okay{"password changed", fiber.StatusOK}
+
+	err = callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+
+	if session.ID != model.EmptyID {
+		u.setUserSession(handler, session)
+	}
+
+	return err
+}
+
+// RecentAuthentication requires the session to be authenticated less than
+// maxAge ago, otherwise it responds with a reauthentication challenge. Signing
+// in does not count for remember me sessions, they must be reauthenticated.
+// Impersonated sessions are never allowed.
+func (u *UserSession) RecentAuthentication(maxAge time.Duration) fiber.Handler {
+	return func(handler *fiber.Ctx) error {
+		impersonatorID, _ := handler.Locals("impersonatorID").(model.ID)
+		if impersonatorID != model.EmptyID {
+			return handler.Status(fiber.StatusForbidden).
+				JSON(sent{errs.ErrImpersonatedSession.Error()})
+		}
+
+		authenticatedAt, ok := handler.Locals("authenticatedAt").(time.Time)
+		rememberMe, _ := handler.Locals("rememberMe").(bool)
+		method, _ := handler.Locals("authenticationMethod").(string)
+
+		if !ok || time.Since(authenticatedAt) > maxAge ||
+			(rememberMe && method != model.AuthenticationReauthentication) {
+			handler.Set(fiber.HeaderWWWAuthenticate, `Session error="`+reauthenticationRequired+`"`)
+
+			return handler.Status(fiber.StatusUnauthorized).JSON(challenge{
+				Message: errs.ErrReauthentication.Error(),
+				Code:    reauthenticationRequired,
+				MaxAge:  int(maxAge.Seconds()),
+			})
+		}
+
+		return handler.Next()
+	}
+}
+
+// Reauthenticate a user session
+//
+//	@Summary		Reauthenticate session
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	sent					"session reauthenticated successfully"
+//	@Failure		400			{object}	sent					"an invalid password was sent"
+//	@Failure		401			{object}	sent					"user session has expired"
+//	@Failure		403			{object}	sent					"operation requires a user session"
+//	@Failure		500			{object}	sent					"internal server error"
+//	@Failure		503			{object}	sent					"too many password operations"
+//	@Param			password	body		model.Reauthentication	true	"password"
+//	@Router			/session/reauthenticate [post]
+//	@Description	Check the password again, allowing operations that require a recent authentication.
+//	@Security		BasicAuth
+func (u *UserSession) Reauthenticate(handler *fiber.Ctx) error {
+	sessionID, ok := handler.Locals("sessionID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting session ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	body := &model.Reauthentication{} //nolint:exhaustruct
+
+	err := handler.BodyParser(body)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	session := model.UserSession{} //nolint:exhaustruct
+
+	funcCore := func() error {
+		sessionTemp, err := u.core.Reauthenticate(sessionID, *body)
+		session = sessionTemp
+
+		return err
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrUserSessionNotFound, fiber.StatusUnauthorized},
+		{errs.ErrUserNotFound, fiber.StatusUnauthorized},
+		{errs.ErrPasswordDoesNotMatch, fiber.StatusBadRequest},
+		{errs.ErrPasswordPoolFull, fiber.StatusServiceUnavailable},
+	}
+
+	unexpectMessageError := "error reauthenticating session"
+
+	okay := okay{"user session reauthenticated", fiber.StatusOK}
+
+	err = callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+
+	if session.ID != model.EmptyID {
+		u.setUserSession(handler, session)
+	}
+
+	return err
+}
+
+// Impersonate a user
+//
+//	@Summary		Impersonate user
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Success		201		{object}	sent	"impersonation started successfully"
+//	@Failure		401		{object}	sent	"user session has expired or must be reauthenticated"
+//	@Failure		403		{object}	sent	"current user is not allowed to impersonate or not using a session"
+//	@Failure		404		{object}	sent	"user does not exist"
+//	@Failure		500		{object}	sent	"internal server error"
+//	@Param			userId	path		string	true	"user ID"
+//	@Router			/session/impersonate/{userId} [post]
+//	@Description	Create a session of the user for the current user, who must have the impersonator role, and set in the response header. Impersonated sessions can not impersonate other users.
+//	@Security		BasicAuth
+func (u *UserSession) Impersonate(handler *fiber.Ctx) error {
+	sessionID, ok := handler.Locals("sessionID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting session ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	userID, err := model.ParseID(handler.Params("userId"))
+	if err != nil {
+		return handler.Status(fiber.StatusNotFound).JSON(sent{errs.ErrUserNotFound.Error()})
+	}
+
+	session := model.UserSession{} //nolint:exhaustruct
+
+	funcCore := func() error {
+		sessionTemp, err := u.core.Impersonate(sessionID, userID, u.impersonatorRole)
+		session = sessionTemp
+
+		return err
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrUserSessionNotFound, fiber.StatusUnauthorized},
+		{errs.ErrNestedImpersonation, fiber.StatusForbidden},
+		{errs.ErrImpersonationDenied, fiber.StatusForbidden},
+		{errs.ErrUserNotFound, fiber.StatusNotFound},
+	}
+
+	unexpectMessageError := "error impersonating user"
+
+	okay := okay{"impersonation started", fiber.StatusCreated}
+
+	err = callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+
+	if session.ID != model.EmptyID {
+		u.setUserSession(handler, session)
+	}
+
+	return err
+}
+
+// Stop impersonating a user
+//
+//	@Summary		Stop impersonation
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	sent	"impersonation stopped successfully"
+//	@Failure		400	{object}	sent	"user session is not impersonating"
+//	@Failure		401	{object}	sent	"user session has expired"
+//	@Failure		403	{object}	sent	"operation requires a user session"
+//	@Failure		500	{object}	sent	"internal server error"
+//	@Router			/session/impersonate [delete]
+//	@Description	Delete the impersonated session, the impersonator must go back to their own session.
+//	@Security		BasicAuth
+func (u *UserSession) StopImpersonation(handler *fiber.Ctx) error {
+	sessionID, ok := handler.Locals("sessionID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting session ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	funcCore := func() error {
+		_, err := u.core.StopImpersonation(sessionID)
+
+		return err
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrUserSessionNotFound, fiber.StatusUnauthorized},
+		{errs.ErrNotImpersonating, fiber.StatusBadRequest},
+	}
+
+	unexpectMessageError := "error stopping impersonation"
+
+	okay := okay{"impersonation stopped", fiber.StatusOK}
+
+	return callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+}
+
+// userSessions sends a page of the active sessions of the user, or of the
+// inactive ones when requested, without the session IDs.
+func (u *UserSession) userSessions(handler *fiber.Ctx, userID model.ID) error {
+	pagination := parsePagination(handler)
+	inactive := handler.QueryBool("inactive")
+
+	funcCore := func() (model.Page[model.UserSessionResponse], error) {
+		get := u.core.GetByUserIDActive
+		if inactive {
+			get = u.core.GetByUserIDInactive
+		}
+
+		userSessions, err := get(userID, pagination)
+		if err != nil {
+			return model.EmptyPage[model.UserSessionResponse](), err
+		}
+
+		linkNextPage(handler, userSessions.NextCursor)
+
+		return model.UserSessionsResponse(userSessions), nil
+	}
+
+	expectErrors := []expectError{{errs.ErrInvalidCursor, fiber.StatusBadRequest}}
+
+	unexpectMessageError := "error getting sessions"
+
+	return callingCoreWithReturn(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		fiber.StatusOK,
+		u.getTranslator(handler),
+		handler,
+	)
+}
+
+// Get the sessions of the current user
+//
+//	@Summary		Get current user sessions
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	model.Page[model.UserSessionResponse]	"current user sessions"
+//	@Failure		400			{object}	sent									"an invalid cursor was sent"
+//	@Failure		401			{object}	sent									"user session has expired"
+//	@Failure		500			{object}	sent									"internal server error"
+//	@Param			inactive	query		bool									false	"get the inactive sessions instead of the active ones"
+//	@Param			cursor		query		string									false	"cursor of the page, from nextCursor"
+//	@Param			qt			query		string									false	"quantity sessions per page"
+//	@Param			total		query		bool									false	"also count all the sessions"
+//	@Header			200			{string}	Link									"link to the next page"
+//	@Router			/me/session [get]
+//	@Description	Get the sessions of the user of the current session, sorted by creation. Sessions impersonating a user are sessions of the impersonator.
+//	@Security		BasicAuth
+func (u *UserSession) GetMine(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	return u.userSessions(handler, userID)
+}
+
+// Get the sessions of a user
+//
+//	@Summary		Get user sessions
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	model.Page[model.UserSessionResponse]	"user sessions"
+//	@Failure		400			{object}	sent									"an invalid cursor was sent"
+//	@Failure		401			{object}	sent									"user session has expired"
+//	@Failure		403			{object}	sent									"current user is not the user nor admin"
+//	@Failure		404			{object}	sent									"user does not exist"
+//	@Failure		500			{object}	sent									"internal server error"
+//	@Param			id			path		string									true	"user id"
+//	@Param			inactive	query		bool									false	"get the inactive sessions instead of the active ones"
+//	@Param			cursor		query		string									false	"cursor of the page, from nextCursor"
+//	@Param			qt			query		string									false	"quantity sessions per page"
+//	@Param			total		query		bool									false	"also count all the sessions"
+//	@Header			200			{string}	Link									"link to the next page"
+//	@Router			/user/{id}/session [get]
+//	@Description	Get the sessions of a user, sorted by creation, only for the user and admins. Sessions impersonating a user are sessions of the impersonator.
+//	@Security		BasicAuth
+func (u *UserSession) GetByUserID(handler *fiber.Ctx) error {
+	id, err := model.ParseID(handler.Params("id", "invalid-id"))
+	if err != nil {
+		return handler.Status(fiber.StatusNotFound).
+			JSON(sent{errs.ErrUserNotFound.Error()})
+	}
+
+	return u.userSessions(handler, id)
+}
+
+// SessionRequired blocks requests authenticated by a token, for operations
+// that only make sense for sessions or that must not be done by scripts.
+func (u *UserSession) SessionRequired(handler *fiber.Ctx) error {
+	_, ok := handler.Locals("tokenID").(model.ID)
+	if ok {
+		return handler.Status(fiber.StatusForbidden).
+			JSON(sent{errs.ErrSessionRequired.Error()})
+	}
+
+	return handler.Next()
+}
+
+// roles returns the roles of the request, the roles of the token when it is
+// authenticated by one and the roles of the user otherwise.
+func (u *UserSession) roles(handler *fiber.Ctx) ([]string, error) {
+	roles, ok := handler.Locals("roles").([]string)
+	if ok {
+		return roles, nil
+	}
+
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		return nil, errs.ErrUserNotFound
+	}
+
+	user, err := u.user.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user roles: %w", err)
+	}
+
+	return user.Roles, nil
+}
+
+// SelfOrAdmin allows operations on the user of the id param only to that user
+// and to admins. A token must have the admin role to be used as an admin.
+func (u *UserSession) SelfOrAdmin(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	id, err := model.ParseID(handler.Params("id", "invalid-id"))
+	if err == nil && id == userID {
+		return handler.Next()
+	}
+
+	return u.AdminRequired(handler)
+}
+
+// AdminRequired allows the operation only to admins. A token must have the
+// admin role to be used as an admin.
+func (u *UserSession) AdminRequired(handler *fiber.Ctx) error {
+	roles, err := u.roles(handler)
+	if err != nil {
+		log.Printf("[ERROR] - error getting roles: %s", err)
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error getting roles"})
+	}
+
+	if u.adminRole == "" || !slices.Contains(roles, u.adminRole) {
+		return handler.Status(fiber.StatusForbidden).
+			JSON(sent{errs.ErrUserNotAdmin.Error()})
+	}
+
+	return handler.Next()
+}
+
+// AdminForDeleted allows listing deleted records, with the includeDeleted or
+// onlyDeleted query params, only to admins.
+func (u *UserSession) AdminForDeleted(handler *fiber.Ctx) error {
+	if !handler.QueryBool("includeDeleted") && !handler.QueryBool("onlyDeleted") {
+		return handler.Next()
+	}
+
+	return u.AdminRequired(handler)
+}
+
+// Unrestricted blocks sessions that can only be used to change the password.
+func (u *UserSession) Unrestricted(handler *fiber.Ctx) error {
+	restricted, ok := handler.Locals("restricted").(bool)
+	if ok && restricted {
+		return handler.Status(fiber.StatusForbidden).
+			JSON(sent{errs.ErrPasswordExpired.Error()})
+	}
+
+	return handler.Next()
+}
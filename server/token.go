@@ -0,0 +1,206 @@
+package server
+
+import (
+	"log"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiago-felipe-99/autenticacao/core"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+type Token struct {
+	core       *core.Token
+	translator *ut.UniversalTranslator
+	languages  []string
+}
+
+func (t *Token) getTranslator(handler *fiber.Ctx) ut.Translator { //nolint:ireturn
+	accept := handler.AcceptsLanguages(t.languages...)
+	if accept == "" {
+		accept = t.languages[0]
+	}
+
+	language, _ := t.translator.GetTranslator(accept)
+
+	return language
+}
+
+// Get the tokens of a user
+//
+//	@Summary		Get user tokens
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Success		200		{object}	model.Page[model.Token]	"user tokens"
+//	@Failure		400		{object}	sent					"an invalid cursor was sent"
+//	@Failure		401		{object}	sent					"user session has expired"
+//	@Failure		403		{object}	sent					"current user is not the user nor admin"
+//	@Failure		404		{object}	sent					"user does not exist"
+//	@Failure		500		{object}	sent					"internal server error"
+//	@Param			id		path		string					true	"user id"
+//	@Param			cursor	query		string					false	"cursor of the page, from nextCursor"
+//	@Param			qt		query		string					false	"quantity tokens per page"
+//	@Param			total	query		bool					false	"also count all the tokens"
+//	@Header			200		{string}	Link					"link to the next page"
+//	@Router			/user/{id}/token [get]
+//	@Description	Get the tokens of a user, without their secrets.
+//	@Security		BasicAuth
+func (t *Token) GetByUserID(handler *fiber.Ctx) error {
+	id, err := model.ParseID(handler.Params("id", "invalid-id"))
+	if err != nil {
+		return handler.Status(fiber.StatusNotFound).
+			JSON(sent{errs.ErrUserNotFound.Error()})
+	}
+
+	pagination := parsePagination(handler)
+
+	funcCore := func() (model.Page[model.Token], error) {
+		tokens, err := t.core.GetByUserID(id, pagination)
+		if err != nil {
+			return tokens, err
+		}
+
+		linkNextPage(handler, tokens.NextCursor)
+
+		return tokens, nil
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrUserNotFound, fiber.StatusNotFound},
+		{errs.ErrInvalidCursor, fiber.StatusBadRequest},
+	}
+
+	unexpectMessageError := "error getting tokens"
+
+	return callingCoreWithReturn(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		fiber.StatusOK,
+		t.getTranslator(handler),
+		handler,
+	)
+}
+
+// Create a token
+//
+//	@Summary		Create token
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Success		201		{object}	model.TokenCreated	"token created, the secret is only shown once"
+//	@Failure		400		{object}	sent				"an invalid token param was sent"
+//	@Failure		401		{object}	sent				"user session has expired or must be reauthenticated"
+//	@Failure		403		{object}	sent				"tokens, impersonated sessions and other users can not create tokens"
+//	@Failure		404		{object}	sent				"user does not exist"
+//	@Failure		500		{object}	sent				"internal server error"
+//	@Param			id		path		string				true	"user id"
+//	@Param			token	body		model.TokenPartial	true	"token params"
+//	@Router			/user/{id}/token [post]
+//	@Description	Create a token with a subset of the user roles. The token is sent in the Authorization header as "Bearer <token>" instead of a session.
+//	@Security		BasicAuth
+func (t *Token) Create(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	impersonatorID, _ := handler.Locals("impersonatorID").(model.ID)
+	if impersonatorID != model.EmptyID {
+		return handler.Status(fiber.StatusForbidden).
+			JSON(sent{errs.ErrImpersonatedSession.Error()})
+	}
+
+	id, err := model.ParseID(handler.Params("id", "invalid-id"))
+	if err != nil {
+		return handler.Status(fiber.StatusNotFound).
+			JSON(sent{errs.ErrUserNotFound.Error()})
+	}
+
+	body := &model.TokenPartial{} //nolint:exhaustruct
+
+	err = handler.BodyParser(body)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	funcCore := func() (model.TokenCreated, error) { return t.core.Create(userID, id, *body) }
+
+	expectErrors := []expectError{
+		{errs.ErrTokenNotOwner, fiber.StatusForbidden},
+		{errs.ErrUserNotFound, fiber.StatusNotFound},
+		{errs.ErrTokenRoles, fiber.StatusBadRequest},
+		{errs.ErrTokenExpired, fiber.StatusBadRequest},
+	}
+
+	unexpectMessageError := "error creating token"
+
+	return callingCoreWithReturn(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		fiber.StatusCreated,
+		t.getTranslator(handler),
+		handler,
+	)
+}
+
+// Revoke a token
+//
+//	@Summary		Revoke token
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Success		200		{object}	sent	"token revoked"
+//	@Failure		401		{object}	sent	"user session has expired"
+//	@Failure		403		{object}	sent	"current user is not the user nor admin"
+//	@Failure		404		{object}	sent	"token does not exist"
+//	@Failure		500		{object}	sent	"internal server error"
+//	@Param			id		path		string	true	"user id"
+//	@Param			tokenId	path		string	true	"token id"
+//	@Router			/user/{id}/token/{tokenId} [delete]
+//	@Description	Revoke a token of the user, it can not be used anymore.
+//	@Security		BasicAuth
+func (t *Token) Delete(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	id, err := model.ParseID(handler.Params("id", "invalid-id"))
+	if err != nil {
+		return handler.Status(fiber.StatusNotFound).
+			JSON(sent{errs.ErrTokenNotFound.Error()})
+	}
+
+	tokenID, err := model.ParseID(handler.Params("tokenId", "invalid-id"))
+	if err != nil {
+		return handler.Status(fiber.StatusNotFound).
+			JSON(sent{errs.ErrTokenNotFound.Error()})
+	}
+
+	funcCore := func() error { return t.core.Delete(userID, id, tokenID) }
+
+	expectErrors := []expectError{{errs.ErrTokenNotFound, fiber.StatusNotFound}}
+
+	unexpectMessageError := "error revoking token"
+
+	okay := okay{"token revoked", fiber.StatusOK}
+
+	return callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		t.getTranslator(handler),
+		handler,
+	)
+}
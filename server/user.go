@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"log"
 
 	ut "github.com/go-playground/universal-translator"
@@ -12,10 +13,28 @@ import (
 
 type User struct {
 	core       *core.User
+	session    *core.UserSession
+	token      *core.Token
 	translator *ut.UniversalTranslator
 	languages  []string
 }
 
+// deleteUser deletes the user and revokes their sessions and tokens, so they
+// can not be used after the deletion.
+func (u *User) deleteUser(deletedBy model.ID, userID model.ID) error {
+	err := u.core.Delete(userID, deletedBy)
+	if err != nil {
+		return err
+	}
+
+	err = u.session.DeleteByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	return u.token.DeleteByUserID(deletedBy, userID)
+}
+
 func (u *User) getTranslator(handler *fiber.Ctx) ut.Translator { //nolint:ireturn
 	accept := handler.AcceptsLanguages(u.languages...)
 	if accept == "" {
@@ -33,11 +52,15 @@ func (u *User) getTranslator(handler *fiber.Ctx) ut.Translator { //nolint:iretur
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	model.User	"user return"
-//	@Failure		401	{object}	sent		"user session has expired"
-//	@Failure		404	{object}	sent		"user does not exist"
-//	@Failure		500	{object}	sent		"internal server error"
-//	@Param			id	path		string		true	"user id"
+//	@Success		200		{object}	model.UserResponse	"user return"
+//	@Header			200		{string}	ETag				"user version"
+//	@Failure		400		{object}	sent				"an invalid field or expand param was sent"
+//	@Failure		401		{object}	sent				"user session has expired"
+//	@Failure		404		{object}	sent				"user does not exist"
+//	@Failure		500		{object}	sent				"internal server error"
+//	@Param			id		path		string				true	"user id"
+//	@Param			fields	query		string				false	"comma separated fields to return"
+//	@Param			expand	query		string				false	"comma separated expansions: roles, createdBy"
 //	@Router			/user/{id} [get]
 //	@Description	Get user by id.
 //	@Security		BasicAuth
@@ -48,9 +71,33 @@ func (u *User) GetByID(handler *fiber.Ctx) error {
 			JSON(sent{errs.ErrUserNotFound.Error()})
 	}
 
-	funcCore := func() (model.User, error) { return u.core.GetByID(id) }
+	fields, err := parseFieldset(handler, model.UserResponse{}) //nolint:exhaustruct
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	expand := queryList(handler, "expand")
 
-	expectErrors := []expectError{{errs.ErrUserNotFound, fiber.StatusNotFound}}
+	funcCore := func() (any, error) {
+		user, err := u.core.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		handler.Set(fiber.HeaderETag, etag(user.Version))
+
+		expansions, err := u.core.Expand([]model.User{user}, expand)
+		if err != nil {
+			return nil, err
+		}
+
+		return userResource(user, expansions, fields)
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrUserNotFound, fiber.StatusNotFound},
+		{errs.ErrInvalidExpand, fiber.StatusBadRequest},
+	}
 
 	unexpectMessageError := "error getting user"
 
@@ -64,28 +111,53 @@ func (u *User) GetByID(handler *fiber.Ctx) error {
 	)
 }
 
+// usersPage expands a page of users and converts them to resources.
+func (u *User) usersPage(
+	handler *fiber.Ctx,
+	users model.Page[model.User],
+	expand []string,
+	fields fieldset,
+) (model.Page[any], error) {
+	expansions, err := u.core.Expand(users.Items, expand)
+	if err != nil {
+		return model.EmptyPage[any](), err
+	}
+
+	resources, err := usersResource(users.Items, expansions, fields)
+	if err != nil {
+		return model.EmptyPage[any](), err
+	}
+
+	return resourcesPage(handler, users, resources), nil
+}
+
 // Get users by roles
 //
 //	@Summary		Get users by roles
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
-//	@Success		200		{array}		model.User	"user return"
-//	@Failure		400		{object}	sent		"an invalid role param was sent"
-//	@Failure		401		{object}	sent		"user session has expired"
-//	@Failure		404		{object}	sent		"user does not exist"
-//	@Failure		500		{object}	sent		"internal server error"
-//	@Param			roles	query		[]string	true	"roles"
-//	@Param			page	query		string		false	"result page number"
-//	@Param			qt		query		string		false	"quantity user per page"
+//	@Success		200				{object}	model.Page[model.UserResponse]	"user return"
+//	@Failure		400				{object}	sent							"an invalid role, field, expand, deleted or cursor param was sent"
+//	@Failure		401				{object}	sent							"user session has expired"
+//	@Failure		403				{object}	sent							"deleted records were requested and the current user is not admin"
+//	@Failure		404				{object}	sent							"user does not exist"
+//	@Failure		500				{object}	sent							"internal server error"
+//	@Param			roles			query		[]string						true	"roles"
+//	@Param			cursor			query		string							false	"cursor of the page, from nextCursor"
+//	@Param			qt				query		string							false	"quantity user per page"
+//	@Param			total			query		bool							false	"also count all the users"
+//	@Param			fields			query		string							false	"comma separated fields to return"
+//	@Param			expand			query		string							false	"comma separated expansions: roles, createdBy"
+//	@Param			includeDeleted	query		bool							false	"also get the deleted users"
+//	@Param			onlyDeleted		query		bool							false	"only get the deleted users"
+//	@Header			200				{string}	Link							"link to the next page"
 //	@Router			/user/roles [get]
-//	@Description	Get users by roles.
+//	@Description	Get users by roles, sorted by creation.
 //	@Security		BasicAuth
 func (u *User) GetByRole(handler *fiber.Ctx) error {
 	query := &struct { //nolint:exhaustruct
 		Roles []string
-		Page  int
-		Qt    int
 	}{}
 
 	err := handler.QueryParser(query)
@@ -93,9 +165,33 @@ func (u *User) GetByRole(handler *fiber.Ctx) error {
 		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
 	}
 
-	funcCore := func() ([]model.User, error) { return u.core.GetByRole(query.Roles, query.Page, query.Qt) }
+	fields, err := parseFieldset(handler, model.UserResponse{}) //nolint:exhaustruct
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
 
-	expectErrors := []expectError{{errs.ErrUserNotFound, fiber.StatusNotFound}}
+	deleted, err := parseDeleted(handler)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	expand := queryList(handler, "expand")
+	pagination := parsePagination(handler)
+
+	funcCore := func() (any, error) {
+		users, err := u.core.GetByRole(query.Roles, deleted, pagination)
+		if err != nil {
+			return nil, err
+		}
+
+		return u.usersPage(handler, users, expand, fields)
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrUserNotFound, fiber.StatusNotFound},
+		{errs.ErrInvalidExpand, fiber.StatusBadRequest},
+		{errs.ErrInvalidCursor, fiber.StatusBadRequest},
+	}
 
 	unexpectMessageError := "error getting users"
 
@@ -115,20 +211,61 @@ func (u *User) GetByRole(handler *fiber.Ctx) error {
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
-//	@Success		200		{array}		model.User	"all roles"
-//	@Failure		401		{object}	sent		"user session has expired"
-//	@Failure		500		{object}	sent		"internal server error"
-//	@Param			page	query		string		false	"result page number"
-//	@Param			qt		query		string		false	"quantity user per page"
+//	@Success		200				{object}	model.Page[model.UserResponse]	"all users"
+//	@Failure		400				{object}	sent							"an invalid field, expand, filter, sort or cursor param was sent"
+//	@Failure		401				{object}	sent							"user session has expired"
+//	@Failure		403				{object}	sent							"deleted records were requested and the current user is not admin"
+//	@Failure		500				{object}	sent							"internal server error"
+//	@Param			cursor			query		string							false	"cursor of the page, from nextCursor"
+//	@Param			qt				query		string							false	"quantity user per page"
+//	@Param			total			query		bool							false	"also count all the users"
+//	@Param			fields			query		string							false	"comma separated fields to return"
+//	@Param			expand			query		string							false	"comma separated expansions: roles, createdBy"
+//	@Param			name			query		string							false	"name substring, ignoring case"
+//	@Param			username		query		string							false	"username substring, ignoring case"
+//	@Param			email			query		string							false	"email substring, ignoring case"
+//	@Param			isActive		query		bool							false	"only active or inactive users"
+//	@Param			role			query		string							false	"only users with the role"
+//	@Param			createdFrom		query		string							false	"users created at or after, RFC 3339"
+//	@Param			createdTo		query		string							false	"users created at or before, RFC 3339"
+//	@Param			createdBy		query		string							false	"ID of the user creator"
+//	@Param			sort			query		string							false	"comma separated sort fields, prefixed with '-' for descending: name, username, email, createdAt, passwordChangedAt"
+//	@Param			includeDeleted	query		bool							false	"also get the deleted users"
+//	@Param			onlyDeleted		query		bool							false	"only get the deleted users"
+//	@Header			200				{string}	Link							"link to the next page"
 //	@Router			/user [get]
-//	@Description	Get all user
+//	@Description	Get all user, filtered and sorted by the query params. Without sort the users are sorted by creation.
 //	@Security		BasicAuth
 func (u *User) GetAll(handler *fiber.Ctx) error {
-	page, qt := handler.QueryInt("page"), handler.QueryInt("qt", defaultQtResults)
+	pagination := parsePagination(handler)
+
+	fields, err := parseFieldset(handler, model.UserResponse{}) //nolint:exhaustruct
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
 
-	funcCore := func() ([]model.User, error) { return u.core.GetAll(page, qt) }
+	expand := queryList(handler, "expand")
 
-	expectErrors := []expectError{}
+	filter, err := parseUserFilter(handler)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	funcCore := func() (any, error) {
+		users, err := u.core.Search(filter, pagination)
+		if err != nil {
+			return nil, err
+		}
+
+		return u.usersPage(handler, users, expand, fields)
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrInvalidExpand, fiber.StatusBadRequest},
+		{errs.ErrInvalidSort, fiber.StatusBadRequest},
+		{errs.ErrInvalidFilter, fiber.StatusBadRequest},
+		{errs.ErrInvalidCursor, fiber.StatusBadRequest},
+	}
 
 	unexpectMessageError := "error getting users"
 
@@ -150,10 +287,11 @@ func (u *User) GetAll(handler *fiber.Ctx) error {
 //	@Produce		json
 //	@Success		201		{object}	sent				"create user successfully"
 //	@Failure		400		{object}	sent				"an invalid user param was sent"
-//	@Failure		401		{object}	sent				"user session has expired"
+//	@Failure		401		{object}	sent				"user session has expired or must be reauthenticated"
 //	@Failure		403		{object}	sent				"current user is not admin"
 //	@Failure		409		{object}	sent				"username/email already exist"
 //	@Failure		500		{object}	sent				"internal server error"
+//	@Failure		503		{object}	sent				"too many password operations"
 //	@Param			user	body		model.UserPartial	true	"user params"
 //	@Router			/user [post]
 //	@Description	Create a user.
@@ -191,6 +329,7 @@ func (u *User) Create(handler *fiber.Ctx) error {
 		{errs.ErrRoleNotFound, fiber.StatusBadRequest},
 		{errs.ErrUsernameAlreadyExist, fiber.StatusConflict},
 		{errs.ErrEmailAlreadyExist, fiber.StatusConflict},
+		{errs.ErrPasswordPoolFull, fiber.StatusServiceUnavailable},
 	}
 
 	unexpectMessageError := "error creating user"
@@ -205,23 +344,93 @@ func (u *User) Create(handler *fiber.Ctx) error {
 	)
 }
 
-// Update a user
+// Import a user
 //
-//	@Summary		Update user
+//	@Summary		Import user
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
-//	@Success		200		{object}	sent				"update user successfully"
-//	@Failure		400		{object}	sent				"an invalid user param was sent"
-//	@Failure		401		{object}	sent				"user session has expired"
+//	@Success		201		{object}	sent				"import user successfully"
+//	@Failure		400		{object}	sent				"an invalid user param or password hash was sent"
+//	@Failure		401		{object}	sent				"user session has expired or must be reauthenticated"
 //	@Failure		403		{object}	sent				"current user is not admin"
-//	@Failure		404		{object}	sent				"user does not exist"
 //	@Failure		409		{object}	sent				"username/email already exist"
 //	@Failure		500		{object}	sent				"internal server error"
-//	@Param			user	body		model.UserUpdate	true	"user params"
-//	@Param			id		path		string				true	"user id"
+//	@Param			user	body		model.UserImport	true	"user params"
+//	@Router			/user/import [post]
+//	@Description	Create a user with a password hash from another system. Hashes with parameters above the limits are rejected.
+//	@Security		BasicAuth
+func (u *User) Import(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error refreshing session"})
+	}
+
+	body := &model.UserImport{} //nolint:exhaustruct
+
+	err := handler.BodyParser(body)
+	if err != nil {
+		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
+	}
+
+	funcCore := func() (any, error) {
+		id, err := u.core.Import(userID, *body)
+		response := struct {
+			ID      model.ID `json:"id"`
+			Message string   `json:"message"`
+		}{
+			ID:      id,
+			Message: "user imported",
+		}
+
+		return response, err
+	}
+
+	expectErrors := []expectError{
+		{errs.ErrRoleNotFound, fiber.StatusBadRequest},
+		{errs.ErrHashUnknown, fiber.StatusBadRequest},
+		{errs.ErrHashParameters, fiber.StatusBadRequest},
+		{errs.ErrUsernameAlreadyExist, fiber.StatusConflict},
+		{errs.ErrEmailAlreadyExist, fiber.StatusConflict},
+		{errs.ErrPasswordPoolFull, fiber.StatusServiceUnavailable},
+	}
+
+	unexpectMessageError := "error importing user"
+
+	return callingCoreWithReturn(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		fiber.StatusCreated,
+		u.getTranslator(handler),
+		handler,
+	)
+}
+
+// Update a user
+//
+//	@Summary		Update user
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	sent				"update user successfully"
+//	@Failure		400			{object}	sent				"an invalid user param was sent"
+//	@Failure		401			{object}	sent				"user session has expired or must be reauthenticated"
+//	@Failure		403			{object}	sent				"current user is not admin"
+//	@Failure		404			{object}	sent				"user does not exist"
+//	@Failure		409			{object}	sent				"username/email already exist or, with If-Match "*", user was modified concurrently"
+//	@Failure		412			{object}	sent				"user was modified since the If-Match version"
+//	@Failure		428			{object}	sent				"If-Match header is required"
+//	@Failure		500			{object}	sent				"internal server error"
+//	@Failure		503			{object}	sent				"too many password operations"
+//	@Param			user		body		model.UserUpdate	true	"user params"
+//	@Param			id			path		string				true	"user id"
+//	@Param			If-Match	header		string				true	"version of the user, from the ETag"
 //	@Router			/user/{id} [put]
-//	@Description	Update a user informations.
+//	@Description	Update a user informations, only when the user is still at the If-Match version. With If-Match "*" the update is applied over concurrent changes.
 //	@Security		BasicAuth
 func (u *User) Update(handler *fiber.Ctx) error {
 	id, err := model.ParseID(handler.Params("id", "invalid-id"))
@@ -230,6 +439,15 @@ func (u *User) Update(handler *fiber.Ctx) error {
 			JSON(sent{errs.ErrUserNotFound.Error()})
 	}
 
+	version, err := parseIfMatch(handler)
+	if errors.Is(err, errs.ErrVersionRequired) {
+		return handler.Status(fiber.StatusPreconditionRequired).JSON(sent{err.Error()})
+	}
+
+	if err != nil {
+		return handler.Status(fiber.StatusPreconditionFailed).JSON(sent{err.Error()})
+	}
+
 	body := &model.UserUpdate{} //nolint:exhaustruct
 
 	err = handler.BodyParser(body)
@@ -237,13 +455,22 @@ func (u *User) Update(handler *fiber.Ctx) error {
 		return handler.Status(fiber.StatusBadRequest).JSON(sent{err.Error()})
 	}
 
-	funcCore := func() error { return u.core.Update(id, *body) }
+	funcCore := func() error { return u.core.Update(id, version, *body) }
+
+	// with "*" there is no precondition to fail, the update only fails when
+	// concurrent updates keep modifying the user
+	mismatch := fiber.StatusPreconditionFailed
+	if version == model.AnyVersion {
+		mismatch = fiber.StatusConflict
+	}
 
 	expectErrors := []expectError{
 		{errs.ErrUserNotFound, fiber.StatusNotFound},
+		{errs.ErrUserVersionMismatch, mismatch},
 		{errs.ErrUsernameAlreadyExist, fiber.StatusConflict},
 		{errs.ErrEmailAlreadyExist, fiber.StatusConflict},
 		{errs.ErrRoleNotFound, fiber.StatusBadRequest},
+		{errs.ErrPasswordPoolFull, fiber.StatusServiceUnavailable},
 	}
 
 	unexpectMessageError := "error updating user"
@@ -267,13 +494,13 @@ func (u *User) Update(handler *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{object}	sent	"user deleted"
-//	@Failure		401	{object}	sent	"user session has expired"
+//	@Failure		401	{object}	sent	"user session has expired or must be reauthenticated"
 //	@Failure		403	{object}	sent	"current user is not admin"
 //	@Failure		404	{object}	sent	"user does not exist"
 //	@Failure		500	{object}	sent	"internal server error"
 //	@Param			id	path		string	true	"user id"
 //	@Router			/user/{id} [delete]
-//	@Description	Delete a user.
+//	@Description	Delete a user, revoking all their sessions and tokens.
 //	@Security		BasicAuth
 func (u *User) Delete(handler *fiber.Ctx) error {
 	userID, ok := handler.Locals("userID").(model.ID)
@@ -290,7 +517,7 @@ func (u *User) Delete(handler *fiber.Ctx) error {
 			JSON(sent{errs.ErrUserNotFound.Error()})
 	}
 
-	funcCore := func() error { return u.core.Delete(userID, id) }
+	funcCore := func() error { return u.deleteUser(userID, id) }
 
 	expectErrors := []expectError{{errs.ErrUserNotFound, fiber.StatusNotFound}}
 
@@ -307,3 +534,57 @@ func (u *User) Delete(handler *fiber.Ctx) error {
 		handler,
 	)
 }
+
+// Restore a user
+//
+//	@Summary		Restore user
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	sent	"user restored"
+//	@Failure		401	{object}	sent	"user session has expired or must be reauthenticated"
+//	@Failure		403	{object}	sent	"current user is not admin"
+//	@Failure		404	{object}	sent	"user does not exist"
+//	@Failure		409	{object}	sent	"user is not deleted or username/email already exist"
+//	@Failure		500	{object}	sent	"internal server error"
+//	@Param			id	path		string	true	"user id"
+//	@Router			/user/{id}/restore [post]
+//	@Description	Restore a deleted user.
+//	@Security		BasicAuth
+func (u *User) Restore(handler *fiber.Ctx) error {
+	userID, ok := handler.Locals("userID").(model.ID)
+	if !ok {
+		log.Printf("[ERROR] - error getting user ID")
+
+		return handler.Status(fiber.StatusInternalServerError).
+			JSON(sent{"error getting user ID"})
+	}
+
+	id, err := model.ParseID(handler.Params("id", "invalid-id"))
+	if err != nil {
+		return handler.Status(fiber.StatusNotFound).
+			JSON(sent{errs.ErrUserNotFound.Error()})
+	}
+
+	funcCore := func() error { return u.core.Restore(userID, id) }
+
+	expectErrors := []expectError{
+		{errs.ErrUserNotFound, fiber.StatusNotFound},
+		{errs.ErrUserNotDeleted, fiber.StatusConflict},
+		{errs.ErrUsernameAlreadyExist, fiber.StatusConflict},
+		{errs.ErrEmailAlreadyExist, fiber.StatusConflict},
+	}
+
+	unexpectMessageError := "error restoring user"
+
+	okay := okay{"user restored", fiber.StatusOK}
+
+	return callingCore(
+		funcCore,
+		expectErrors,
+		unexpectMessageError,
+		okay,
+		u.getTranslator(handler),
+		handler,
+	)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/thiago-felipe-99/autenticacao/errs"
+	"github.com/thiago-felipe-99/autenticacao/model"
+)
+
+// etag is the strong entity tag of a version.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch reads the version of the If-Match header, "*" matches any
+// version. A tag that is not a version can not match the current one.
+func parseIfMatch(handler *fiber.Ctx) (int, error) {
+	tag := strings.TrimSpace(handler.Get(fiber.HeaderIfMatch))
+
+	switch tag {
+	case "":
+		return model.AnyVersion, errs.ErrVersionRequired
+	case "*":
+		return model.AnyVersion, nil
+	}
+
+	unquoted, opened := strings.CutPrefix(tag, `"`)
+	unquoted, closed := strings.CutSuffix(unquoted, `"`)
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || !opened || !closed || version < 1 {
+		return model.AnyVersion, errs.ErrUserVersionMismatch
+	}
+
+	return version, nil
+}